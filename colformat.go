@@ -0,0 +1,185 @@
+package utilities
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// ColFormat specifies how the values of a Table column are printed.
+type ColFormat struct {
+	Verb       string // Verb is a printf format, e.g. "%8.3f". If not "", the other fields are ignored.
+	Decimals   *int   // Decimals is the # of places after the decimal. If nil, PrettyString's choice is used. See Places.
+	Percent    bool   // Percent multiplies the value by 100 and appends "%"
+	Currency   string // Currency is a prefix for the value, e.g. "$"
+	Thousands  bool   // Thousands adds comma thousands separators
+	DateLayout string // DateLayout is the time.Format layout for dates. Default is 2006-01-02.
+	SI         bool   // SI scales the value and appends an SI suffix (k, M, G, ...)
+}
+
+// Places returns a pointer to n for ColFormat.Decimals, e.g. &ColFormat{Decimals: Places(2)}.
+func Places(n int) *int {
+	return &n
+}
+
+// ParseColFormat creates a ColFormat from spec. If spec starts with "%", it is a printf format.
+// Otherwise, spec is a ";"-separated list of:
+//   - .N      N places after the decimal
+//   - pct     percent
+//   - cur=X   currency prefix X. "$", "€", "£" and "¥" may be given alone.
+//   - comma   thousands separators
+//   - date=L  date layout L
+//   - si      SI suffix
+//
+// For example, "$;comma;.2" prints 1234.5 as $1,234.50.
+func ParseColFormat(spec string) (*ColFormat, error) {
+	cf := &ColFormat{}
+
+	if strings.HasPrefix(spec, "%") {
+		cf.Verb = spec
+		return cf, nil
+	}
+
+	for _, token := range strings.Split(spec, ";") {
+		token = strings.TrimSpace(token)
+		key, val, _ := strings.Cut(token, "=")
+
+		switch {
+		case token == "":
+		case strings.HasPrefix(token, "."):
+			places, e := strconv.Atoi(token[1:])
+			if e != nil || places < 0 {
+				return nil, fmt.Errorf("bad decimal places %s: ParseColFormat", token)
+			}
+
+			cf.Decimals = &places
+		case token == "pct":
+			cf.Percent = true
+		case token == "$" || token == "€" || token == "£" || token == "¥":
+			cf.Currency = token
+		case key == "cur":
+			cf.Currency = val
+		case token == "comma":
+			cf.Thousands = true
+		case key == "date":
+			cf.DateLayout = val
+		case token == "si":
+			cf.SI = true
+		default:
+			return nil, fmt.Errorf("unknown format %s: ParseColFormat", token)
+		}
+	}
+
+	return cf, nil
+}
+
+// Format returns x formatted according to cf. Missing values (see IsNA) return NAToken.
+func (cf *ColFormat) Format(x any) string {
+	if IsNA(x) {
+		return NAToken
+	}
+
+	if cf.Verb != "" {
+		return fmt.Sprintf(cf.Verb, x)
+	}
+
+	switch val := x.(type) {
+	case time.Time:
+		if cf.DateLayout != "" {
+			return val.Format(cf.DateLayout)
+		}
+
+		return val.Format("2006-01-02")
+	case string:
+		return val
+	}
+
+	f, ok := numberOf(x)
+	if !ok {
+		return PrettyString(x)
+	}
+
+	// nothing specified for numbers
+	if cf.Decimals == nil && !cf.Percent && !cf.SI && !cf.Thousands && cf.Currency == "" {
+		if str := PrettyString(x); str != "" {
+			return str
+		}
+	}
+
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	if cf.Percent {
+		f *= 100
+	}
+
+	suffix := ""
+	if cf.SI {
+		f, suffix = humanize.ComputeSI(f)
+	}
+
+	var places int
+	if cf.Decimals != nil {
+		places = *cf.Decimals
+	} else {
+		switch {
+		case cf.Percent || cf.SI:
+			places = 1
+		case !isFloat(x):
+			places = 0
+		default:
+			places = prettyPlaces(f)
+		}
+	}
+
+	str := strconv.FormatFloat(math.Abs(f), 'f', places, 64)
+	if cf.Thousands {
+		str = addThousands(str)
+	}
+
+	sign := ""
+	if f < 0 && strings.Trim(str, "0.,") != "" {
+		sign = "-"
+	}
+
+	str = sign + cf.Currency + str + suffix
+	if cf.Percent {
+		str += "%"
+	}
+
+	return str
+}
+
+// isFloat returns true if x is a float32 or float64
+func isFloat(x any) bool {
+	switch x.(type) {
+	case float32, float64:
+		return true
+	}
+
+	return false
+}
+
+// addThousands inserts commas into the integer part of the non-negative number str
+func addThousands(str string) string {
+	intPart, frac, hasFrac := strings.Cut(str, ".")
+
+	var sb strings.Builder
+	for ind, ch := range intPart {
+		if ind > 0 && (len(intPart)-ind)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(ch)
+	}
+
+	if hasFrac {
+		sb.WriteString("." + frac)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,161 @@
+package utilities
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// describeStats are the statistics produced by Describe, in order
+var describeStats = []string{"count", "missing", "mean", "std", "min", "q25", "median", "q75", "max", "distinct", "top", "freq"}
+
+// Describe returns a Table of summary statistics of each column of data. The columns are in alphabetical order.
+// See Table.Describe.
+func Describe(data map[string][]any) *Table {
+	var names []string
+	for name := range data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tbl := &Table{ColNames: []string{""}}
+	for _, name := range names {
+		tbl.ColNames = append(tbl.ColNames, name)
+		tbl.Data = append(tbl.Data, data[name])
+	}
+
+	for row := 0; row < MaxInt(0, maxLen(tbl.Data)); row++ {
+		tbl.RowNames = append(tbl.RowNames, fmt.Sprintf("%d", row+1))
+	}
+
+	return tbl.Describe()
+}
+
+// maxLen returns the length of the longest element of cols, -1 if there are none
+func maxLen(cols [][]any) int {
+	mx := -1
+	for _, col := range cols {
+		mx = MaxInt(mx, len(col))
+	}
+
+	return mx
+}
+
+// Describe returns a Table of summary statistics of each column of the table. The statistics are the rows:
+//   - count: number of non-missing values. See IsNA.
+//   - missing: number of missing values
+//   - mean, std, q25, median, q75: for numeric columns
+//   - min, max: for numeric and date columns
+//   - distinct: number of distinct non-missing values
+//   - top, freq: the most frequent value and its count, for string and date columns
+//
+// Statistics that do not apply to a column are nil.
+func (cd *Table) Describe() *Table {
+	out := &Table{RowNames: append([]string{}, describeStats...), ColNames: []string{"statistic"}}
+
+	for col := 0; col < len(cd.Data); col++ {
+		name := ""
+		if col+1 < len(cd.ColNames) {
+			name = cd.ColNames[col+1]
+		}
+
+		out.ColNames = append(out.ColNames, name)
+		out.Data = append(out.Data, describeColumn(cd.Data[col]))
+	}
+
+	return out
+}
+
+// describeColumn returns the statistics in describeStats for col
+func describeColumn(col []any) []any {
+	stats := make(map[string]any)
+
+	var vals []any
+	for _, x := range col {
+		if !IsNA(x) {
+			vals = append(vals, x)
+		}
+	}
+
+	stats["count"] = int64(len(vals))
+	stats["missing"] = int64(len(col) - len(vals))
+
+	// counts of each distinct value, and the first occurrence of each
+	counts := make(map[string]int)
+	var levels []any
+	for _, x := range vals {
+		key := fmt.Sprintf("%T:%s", x, RawString(x))
+		if counts[key] == 0 {
+			levels = append(levels, x)
+		}
+		counts[key]++
+	}
+
+	stats["distinct"] = int64(len(levels))
+
+	switch {
+	case len(vals) == 0:
+	case isNumeric(vals):
+		xs := make([]float64, len(vals))
+		for ind, x := range vals {
+			xs[ind], _ = numberOf(x)
+		}
+		sort.Float64s(xs)
+
+		stats["mean"] = stat.Mean(xs, nil)
+		if len(xs) > 1 {
+			stats["std"] = stat.StdDev(xs, nil)
+		}
+
+		stats["min"], stats["max"] = xs[0], xs[len(xs)-1]
+		stats["q25"] = stat.Quantile(0.25, stat.Empirical, xs, nil)
+		stats["median"] = stat.Quantile(0.5, stat.Empirical, xs, nil)
+		stats["q75"] = stat.Quantile(0.75, stat.Empirical, xs, nil)
+	default:
+		if allDates(vals) {
+			mn, mx := vals[0].(time.Time), vals[0].(time.Time)
+			for _, x := range vals {
+				dt := x.(time.Time)
+				if dt.Before(mn) {
+					mn = dt
+				}
+
+				if dt.After(mx) {
+					mx = dt
+				}
+			}
+
+			stats["min"], stats["max"] = mn, mx
+		}
+
+		// levels is in order of first occurrence, so ties go to the earliest
+		top, freq := levels[0], 0
+		for _, lvl := range levels {
+			if n := counts[fmt.Sprintf("%T:%s", lvl, RawString(lvl))]; n > freq {
+				top, freq = lvl, n
+			}
+		}
+
+		stats["top"], stats["freq"] = top, int64(freq)
+	}
+
+	out := make([]any, len(describeStats))
+	for ind, name := range describeStats {
+		out[ind] = stats[name]
+	}
+
+	return out
+}
+
+// allDates returns true if every element of vals is a time.Time
+func allDates(vals []any) bool {
+	for _, x := range vals {
+		if _, ok := x.(time.Time); !ok {
+			return false
+		}
+	}
+
+	return true
+}
@@ -5,6 +5,7 @@ go 1.22
 toolchain go1.22.0
 
 require (
+	github.com/ClickHouse/clickhouse-go/v2 v2.18.0
 	github.com/MetalBlueberry/go-plotly v0.4.0
 	github.com/dustin/go-humanize v1.0.1
 	github.com/invertedv/chutils v1.1.34
@@ -16,7 +17,6 @@ require (
 
 require (
 	github.com/ClickHouse/ch-go v0.61.2 // indirect
-	github.com/ClickHouse/clickhouse-go/v2 v2.18.0 // indirect
 	github.com/andybalholm/brotli v1.1.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/go-faster/city v1.0.1 // indirect
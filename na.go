@@ -0,0 +1,171 @@
+package utilities
+
+import (
+	"math"
+	"reflect"
+	"time"
+)
+
+// NAToken is the string PrettyString returns for missing values (see IsNA)
+var NAToken = ""
+
+// NAValues are values, in addition to the defaults, that IsNA treats as missing -- e.g. -999 or "NA".
+// Numeric values match numbers of any type with the same value.
+var NAValues []any
+
+// IsNA returns true if x is a missing value. Missing values are nil, nil pointers, NaN, "", the zero time
+// and any element of NAValues. Pointers are dereferenced.
+func IsNA(x any) bool {
+	if x == nil {
+		return true
+	}
+
+	if val := reflect.ValueOf(x); val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return true
+		}
+
+		return IsNA(val.Elem().Interface())
+	}
+
+	switch val := x.(type) {
+	case float64:
+		if math.IsNaN(val) {
+			return true
+		}
+	case float32:
+		if math.IsNaN(float64(val)) {
+			return true
+		}
+	case string:
+		if val == "" {
+			return true
+		}
+	case time.Time:
+		if val.IsZero() {
+			return true
+		}
+	}
+
+	for _, na := range NAValues {
+		if isSame(x, na) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// naValue returns nil if x is missing (see IsNA), otherwise x with pointers dereferenced
+func naValue(x any) any {
+	if IsNA(x) {
+		return nil
+	}
+
+	if val := reflect.ValueOf(x); val.Kind() == reflect.Pointer {
+		return naValue(val.Elem().Interface())
+	}
+
+	return x
+}
+
+// isSame returns true if x and y are equal. Numbers of different types are equal if their values are.
+func isSame(x, y any) bool {
+	if xf, ok := numberOf(x); ok {
+		yf, ok := numberOf(y)
+		return ok && xf == yf
+	}
+
+	tx := reflect.TypeOf(x)
+	if tx != reflect.TypeOf(y) || !tx.Comparable() {
+		return false
+	}
+
+	return x == y
+}
+
+// DropEmptyRows removes the rows of the table whose elements are all missing (see IsNA).
+func (cd *Table) DropEmptyRows() {
+	cd.dropRows(func(row int) bool {
+		for col := 0; col < len(cd.Data); col++ {
+			if !IsNA(cd.Data[col][row]) {
+				return false
+			}
+		}
+
+		return true
+	})
+}
+
+// DropNARows removes the rows of the table that have any missing element (see IsNA).
+func (cd *Table) DropNARows() {
+	cd.dropRows(func(row int) bool {
+		for col := 0; col < len(cd.Data); col++ {
+			if IsNA(cd.Data[col][row]) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// DropEmptyCols removes the columns of the table whose elements are all missing (see IsNA).
+func (cd *Table) DropEmptyCols() {
+	var (
+		data     [][]any
+		colNames []string
+	)
+
+	if len(cd.ColNames) > 0 {
+		colNames = append(colNames, cd.ColNames[0])
+	}
+
+	for col := 0; col < len(cd.Data); col++ {
+		empty := true
+		for _, x := range cd.Data[col] {
+			if !IsNA(x) {
+				empty = false
+				break
+			}
+		}
+
+		name := ""
+		if col+1 < len(cd.ColNames) {
+			name = cd.ColNames[col+1]
+		}
+
+		if empty {
+			delete(cd.Formats, name)
+			continue
+		}
+
+		data = append(data, cd.Data[col])
+		colNames = append(colNames, name)
+	}
+
+	cd.Data, cd.ColNames = data, colNames
+}
+
+// dropRows removes the rows of the table for which drop is true
+func (cd *Table) dropRows(drop func(row int) bool) {
+	var rowNames []string
+
+	data := make([][]any, len(cd.Data))
+
+	for row := 0; row < cd.nRows(); row++ {
+		if drop(row) {
+			continue
+		}
+
+		if row < len(cd.RowNames) {
+			rowNames = append(rowNames, cd.RowNames[row])
+		}
+
+		for col := 0; col < len(cd.Data); col++ {
+			data[col] = append(data[col], cd.Data[col][row])
+		}
+	}
+
+	cd.RowNames, cd.Data = rowNames, data
+}
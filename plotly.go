@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"regexp"
 	"strings"
 	"time"
 
@@ -254,11 +255,18 @@ func HTML2File(htmlFile string, plotType PlotlyImage, outDir, outFile string) er
 		return fmt.Errorf("not a plotly html file: %s", htmlFile)
 	}
 
-	var jsonStr string
-	if jsonStr, err = Matched(plotStr[indx+10:], "(", ")"); err != nil {
+	// the JSON is quoted and may contain parentheses
+	var matches []Match
+	if matches, err = matchAll(plotStr[indx+10:], "(", ")", []string{"'", `"`}, true); err != nil {
 		return err
 	}
 
+	if len(matches) == 0 {
+		return fmt.Errorf("no JSON in plotly html file: %s", htmlFile)
+	}
+
+	jsonStr := matches[0].Text
+
 	tempFileName := TempFile("js", nameLength)
 
 	var tempFile *os.File
@@ -306,6 +314,10 @@ func NewHistData(rootQry, field, where string, conn *chutils.Connect) (*HistData
 	defer func() { _ = rdr.Close() }()
 
 	if e := rdr.Init("", chutils.MergeTree); e != nil {
+		if ef := checkFields(rootQry, conn, field); ef != nil {
+			return nil, ef
+		}
+
 		return nil, e
 	}
 
@@ -390,6 +402,10 @@ func NewQuantileData(rootQry, field, where string, conn *chutils.Connect) (*Quan
 	defer func() { _ = rdr.Close() }()
 
 	if ex := rdr.Init("", chutils.MergeTree); ex != nil {
+		if ef := checkFields(rootQry, conn, field); ef != nil {
+			return nil, ef
+		}
+
 		return nil, ex
 	}
 	_, outQ.FieldDef, _ = rdr.TableSpec().Get(field)
@@ -451,7 +467,11 @@ func NewXYData(rootQry, where, fields, colors, lineTypes string, conn *chutils.C
 
 	colX, startCol := 0, 0
 	if len(fieldsSlc) > 1 {
-		colX, outXY.XfieldDef, _ = rdr.TableSpec().Get(fieldsSlc[0])
+		xField := strings.Trim(fieldsSlc[0], " ")
+		if colX, outXY.XfieldDef, err = rdr.TableSpec().Get(xField); err != nil {
+			return nil, unknownField(xField, rdr.TableSpec().FieldList())
+		}
+
 		startCol = 1
 	}
 
@@ -469,7 +489,7 @@ func NewXYData(rootQry, where, fields, colors, lineTypes string, conn *chutils.C
 		)
 
 		if colY, fldDefY, err = rdr.TableSpec().Get(yField); err != nil {
-			return nil, err
+			return nil, unknownField(yField, rdr.TableSpec().FieldList())
 		}
 
 		outXY.YfieldDef = append(outXY.YfieldDef, fldDefY)
@@ -523,6 +543,36 @@ func NewXYData(rootQry, where, fields, colors, lineTypes string, conn *chutils.C
 	return outXY, nil
 }
 
+// identifier matches a plain field name, as opposed to an expression
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// unknownField returns an error for field, which is not among fields, suggesting the closest of fields
+func unknownField(field string, fields []string) error {
+	return fmt.Errorf("unknown field '%s'%s", field, DidYouMean(field, fields))
+}
+
+// checkFields returns an error for the first of fields that is a plain field name but not a field of
+// rootQry. Expressions are not checked. nil is returned if rootQry cannot be run.
+// It is called when a query fails, since ClickHouse's error for a mistyped field is not helpful.
+func checkFields(rootQry string, conn *chutils.Connect, fields ...string) error {
+	rdr := s.NewReader(rootQry, conn)
+	defer func() { _ = rdr.Close() }()
+
+	if e := rdr.Init("", chutils.MergeTree); e != nil {
+		return nil
+	}
+
+	have := rdr.TableSpec().FieldList()
+	for _, field := range fields {
+		field = strings.Trim(field, " ")
+		if identifier.MatchString(field) && !Contains(field, have...) {
+			return unknownField(field, have)
+		}
+	}
+
+	return nil
+}
+
 // toSlice pulls a column out of x
 func toSlice(x []chutils.Row, col int) []any {
 	var out []any
@@ -1,138 +1,121 @@
 package utilities
 
 import (
-	"os"
 	"strings"
-	"time"
 )
 
 // Table holds a table
 type Table struct {
 	RowNames []string
-	ColNames []string
-	Data     [][]any // stored by columns
-	markdown bool
+	ColNames []string              // ColNames[0] is the heading of the RowNames column
+	Data     [][]any               // stored by columns
+	Formats  map[string]*ColFormat // Formats are optional formats by column name. Default is PrettyString.
 }
 
 // Write writes the table to a file.  If markDown a markdown table is created.
 func (cd *Table) Write(outFile string, markDown bool) error {
-	// write out file
-	var (
-		fl  *os.File
-		err error
-	)
-
-	cd.markdown = markDown
-
-	if fl, err = os.Create(outFile); err != nil {
-		return err
+	format := TablePlain
+	if markDown {
+		format = TableMarkdown
 	}
-	defer func() { _ = fl.Close() }()
-
-	if _, e := fl.WriteString(cd.String()); e != nil {
-		return e
-	}
-
-	cd.markdown = false
 
-	return nil
+	return cd.WriteFormat(outFile, format, false)
 }
 
-// CleanUp removes empty rows. A row is not empty if it has an element that is float/int/date or a string
-// that are not ""
+// CleanUp removes empty rows. A row is empty if all its elements are missing (see IsNA).
+// See also DropEmptyRows, DropEmptyCols and DropNARows.
 func (cd *Table) CleanUp() {
-	var (
-		rowNames []string
-	)
-
-	nCol := len(cd.Data)
-	nRow := len(cd.Data[0])
-	dataVal := make([][]any, nCol)
-
-	for row := 0; row < nRow; row++ {
-		ok := false
-		for col := 0; col < nCol; col++ {
-			switch x := cd.Data[col][row].(type) {
-			case int, int32, int64, float32, float64, time.Time:
-				ok = true
-			case string:
-				if x != "" {
-					ok = true
-				}
-			}
+	cd.DropEmptyRows()
+}
 
-			if ok {
-				break
-			}
+// Align is the alignment of a column
+type Align int
+
+const (
+	AlignLeft Align = 0 + iota
+	AlignRight
+	AlignCenter
+)
+
+// Pad pads each element of inTable so the columns line up with pad spaces separating them.
+// Widths are measured by DisplayWidth. align gives the alignment of each column; columns beyond the
+// end of align are left-aligned.
+func Pad(inTable [][]string, pad int, align ...Align) string {
+	for row := 0; row < len(inTable); row++ {
+		for col := 0; col < len(inTable[0]); col++ {
+			inTable[row][col] = strings.Trim(inTable[row][col], " ") // get rid of these
 		}
-		// row is empty
-		if ok {
-			rowNames = append(rowNames, cd.RowNames[row])
+	}
+
+	maxes := colWidths(inTable)
 
-			for col := 0; col < nCol; col++ {
-				dataVal[col] = append(dataVal[col], cd.Data[col][row])
+	var sb strings.Builder
+	for row := 0; row < len(inTable); row++ {
+		for col := 0; col < len(inTable[0]); col++ {
+			colAlign := AlignLeft
+			if col < len(align) {
+				colAlign = align[col]
 			}
+
+			sb.WriteString(padCell(inTable[row][col], maxes[col], colAlign))
+			sb.WriteString(strings.Repeat(" ", pad))
 		}
+		sb.WriteString("\n")
 	}
 
-	cd.RowNames = rowNames
-	cd.Data = dataVal
+	return sb.String()
 }
 
-// Pad pads each element of inTable so the columns line up with pad spaces separating them
-func Pad(inTable [][]string, pad int) string {
+// colWidths returns the maximum DisplayWidth of each column of inTable, which is stored by rows
+func colWidths(inTable [][]string) []int {
 	maxes := make([]int, len(inTable[0]))
 
 	for row := 0; row < len(inTable); row++ {
 		for col := 0; col < len(inTable[0]); col++ {
-			inTable[row][col] = strings.Trim(inTable[row][col], " ") // get rid of these
-			if l := len(inTable[row][col]); l > maxes[col] {
+			if l := DisplayWidth(inTable[row][col]); l > maxes[col] {
 				maxes[col] = l
 			}
 		}
 	}
 
-	outString := ""
-	for row := 0; row < len(inTable); row++ {
-		for col := 0; col < len(inTable[0]); col++ {
-			element := inTable[row][col] + strings.Repeat(" ", maxes[col]+pad-len(inTable[row][col]))
-			outString += element
-		}
-		outString += "\n"
-	}
-
-	return outString
+	return maxes
 }
 
-func (cd *Table) String() string {
-	const padLength = 4
-
-	if len(cd.Data) == 0 {
-		return ""
+// padCell pads str with spaces to have display width, aligned according to align
+func padCell(str string, width int, align Align) string {
+	fill := MaxInt(width-DisplayWidth(str), 0)
+
+	switch align {
+	case AlignRight:
+		return strings.Repeat(" ", fill) + str
+	case AlignCenter:
+		return strings.Repeat(" ", fill/2) + str + strings.Repeat(" ", fill-fill/2)
+	default:
+		return str + strings.Repeat(" ", fill)
 	}
+}
 
-	var outSlc [][]string // stored by rows
-
-	// if cd.markdown add pipes to create a Markdown table
-	sep := ""
-	if cd.markdown {
-		sep = "|"
+// aligns returns the alignment of the columns of the table: the RowNames column and string columns are
+// left-aligned, numeric columns are right-aligned.
+func (cd *Table) aligns() []Align {
+	align := make([]Align, len(cd.Data)+1)
+	for col := 0; col < len(cd.Data); col++ {
+		if isNumeric(cd.Data[col]) {
+			align[col+1] = AlignRight
+		}
 	}
 
-	var colNames []string
-	for ind := 0; ind < len(cd.ColNames); ind++ {
-		colNames = append(colNames, cd.ColNames[ind]+sep)
-	}
-	outSlc = append(outSlc, colNames)
+	return align
+}
 
-	for row := 0; row < len(cd.Data[0]); row++ {
-		rowSlc := []string{cd.RowNames[row] + sep}
-		for col := 0; col < len(cd.Data); col++ {
-			rowSlc = append(rowSlc, PrettyString(cd.Data[col][row])+sep)
-		}
+func (cd *Table) String() string {
+	return cd.text(false)
+}
 
-		outSlc = append(outSlc, rowSlc)
-	}
+// text returns the table with the columns lined up. If raw, the values are not passed through PrettyString.
+// Tables that fail check return "".
+func (cd *Table) text(raw bool) string {
+	str, _ := cd.Render(TablePlain, raw)
 
-	return Pad(outSlc, padLength)
+	return str
 }
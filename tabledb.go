@@ -0,0 +1,226 @@
+package utilities
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/invertedv/chutils"
+	s "github.com/invertedv/chutils/sql"
+)
+
+// NewTableFromQuery runs qry and returns the result as a Table. Data holds the values as returned by ClickHouse.
+//   - qry: query to run
+//   - rowNameField: field to use for RowNames. If "", the rows are numbered starting at 1.
+//   - maxRows: maximum number of rows to return. If 0, all rows are returned.
+//   - conn: ClickHouse connection
+func NewTableFromQuery(qry, rowNameField string, maxRows int, conn *chutils.Connect) (*Table, error) {
+	rdr := s.NewReader(qry, conn)
+	defer func() { _ = rdr.Close() }()
+
+	if e := rdr.Init("", chutils.MergeTree); e != nil {
+		return nil, e
+	}
+
+	fields := rdr.TableSpec().FieldList()
+
+	rowCol := -1
+	if rowNameField != "" {
+		var e error
+		if rowCol, _, e = rdr.TableSpec().Get(rowNameField); e != nil {
+			return nil, fmt.Errorf("field %s not in query%s: NewTableFromQuery", rowNameField, DidYouMean(rowNameField, fields))
+		}
+	}
+
+	// Read returns io.EOF if there are fewer than maxRows rows
+	rows, _, e := rdr.Read(maxRows, false)
+	if e != nil && !errors.Is(e, io.EOF) {
+		return nil, e
+	}
+
+	tbl := &Table{ColNames: []string{rowNameField}}
+	for col, field := range fields {
+		if col == rowCol {
+			continue
+		}
+
+		tbl.ColNames = append(tbl.ColNames, field)
+		tbl.Data = append(tbl.Data, make([]any, 0, len(rows)))
+	}
+
+	for ind, row := range rows {
+		rowName := fmt.Sprintf("%d", ind+1)
+		if rowCol >= 0 {
+			rowName = RawString(row[rowCol])
+		}
+
+		tbl.RowNames = append(tbl.RowNames, rowName)
+
+		dataCol := 0
+		for col := 0; col < len(row); col++ {
+			if col == rowCol {
+				continue
+			}
+
+			tbl.Data[dataCol] = append(tbl.Data[dataCol], row[col])
+			dataCol++
+		}
+	}
+
+	return tbl, nil
+}
+
+// CHOptions are the options for Table.ToClickHouse
+type CHOptions struct {
+	Engine    string // Engine is the table engine. Default is MergeTree().
+	OrderBy   string // OrderBy is the ORDER BY key. Default is the RowNames column.
+	BatchSize int    // BatchSize is the number of rows in each INSERT. Default is 10000.
+	Overwrite bool   // Overwrite replaces tableName if it exists. Otherwise, an existing table is an error.
+}
+
+// ToClickHouse creates the ClickHouse table tableName and inserts the table into it.
+// The first column is the RowNames column, named ColNames[0] (or "row" if that is empty).
+// Column types are inferred from Data: Int64 if all values are integers, Float64 if all are numbers,
+// Date if all are time.Time, and String otherwise. Columns with missing values (see IsNA) are Nullable and the
+// missing values are inserted as NULL. opts may be nil.
+func (cd *Table) ToClickHouse(tableName string, opts *CHOptions, conn *chutils.Connect) error {
+	const defaultBatch = 10000
+
+	if e := cd.check(); e != nil {
+		return fmt.Errorf("%v: ToClickHouse", e)
+	}
+
+	chOpts := CHOptions{}
+	if opts != nil {
+		chOpts = *opts
+	}
+
+	if chOpts.Engine == "" {
+		chOpts.Engine = "MergeTree()"
+	}
+
+	if chOpts.BatchSize <= 0 {
+		chOpts.BatchSize = defaultBatch
+	}
+
+	header := ""
+	if len(cd.ColNames) > 0 {
+		header = cd.ColNames[0]
+	}
+
+	rowCol := rowKey(header)
+	if chOpts.OrderBy == "" {
+		chOpts.OrderBy = fmt.Sprintf("`%s`", rowCol)
+	}
+
+	if TableExists(tableName, conn) == nil {
+		if !chOpts.Overwrite {
+			return fmt.Errorf("table %s already exists: ToClickHouse", tableName)
+		}
+
+		if e := DropTable(tableName, conn); e != nil {
+			return e
+		}
+	}
+
+	fields := []string{fmt.Sprintf("`%s` String", rowCol)}
+	for col := 0; col < len(cd.Data); col++ {
+		fields = append(fields, fmt.Sprintf("`%s` %v", cd.ColNames[col+1], chField(cd.Data[col])))
+	}
+
+	qry := fmt.Sprintf("CREATE TABLE %s (%s) ENGINE=%s ORDER BY (%s)",
+		tableName, strings.Join(fields, ", "), chOpts.Engine, chOpts.OrderBy)
+	if _, e := conn.Exec(qry); e != nil {
+		return e
+	}
+
+	for start := 0; start < cd.nRows(); start += chOpts.BatchSize {
+		var rows []string
+		for row := start; row < MinInt(start+chOpts.BatchSize, cd.nRows()); row++ {
+			vals := []string{chString(cd.RowNames[row])}
+			for col := 0; col < len(cd.Data); col++ {
+				vals = append(vals, chLiteral(cd.Data[col][row]))
+			}
+
+			rows = append(rows, "("+strings.Join(vals, ",")+")")
+		}
+
+		if _, e := conn.Exec(fmt.Sprintf("INSERT INTO %s VALUES %s", tableName, strings.Join(rows, ","))); e != nil {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// chField returns the ClickHouse type of a column of Data. Columns with missing values (see IsNA) are Nullable.
+func chField(col []any) chutils.ChField {
+	fld := chutils.ChField{Base: chutils.ChUnknown}
+
+	for _, x := range col {
+		x = naValue(x)
+		var base chutils.ChType
+		switch x.(type) {
+		case nil:
+			if !fld.Funcs.Has(chutils.OuterNullable) {
+				fld.Funcs = append(fld.Funcs, chutils.OuterNullable)
+			}
+			continue
+		case time.Time:
+			base = chutils.ChDate
+		case float32, float64:
+			base = chutils.ChFloat
+		case string:
+			base = chutils.ChString
+		default:
+			base = chutils.ChString
+			if isNumeric([]any{x}) {
+				base = chutils.ChInt
+			}
+		}
+
+		switch {
+		case fld.Base == chutils.ChUnknown || fld.Base == base:
+			fld.Base = base
+		case (fld.Base == chutils.ChInt && base == chutils.ChFloat) || (fld.Base == chutils.ChFloat && base == chutils.ChInt):
+			fld.Base = chutils.ChFloat
+		default:
+			fld.Base = chutils.ChString
+		}
+	}
+
+	switch fld.Base {
+	case chutils.ChUnknown:
+		fld.Base = chutils.ChString
+	case chutils.ChInt, chutils.ChFloat:
+		fld.Length = 64
+	}
+
+	return fld
+}
+
+// chLiteral returns x as a ClickHouse literal for an INSERT. Missing values (see IsNA) are NULL and pointers
+// are dereferenced.
+func chLiteral(x any) string {
+	switch val := naValue(x).(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return chString(val)
+	case time.Time:
+		return "'" + val.Format("2006-01-02") + "'"
+	default:
+		if isNumeric([]any{val}) {
+			return RawString(val)
+		}
+
+		return chString(RawString(val))
+	}
+}
+
+// chString returns str as a quoted ClickHouse string literal
+func chString(str string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(str) + "'"
+}
@@ -0,0 +1,267 @@
+package utilities
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// CellDiff is a cell that differs between two tables
+type CellDiff struct {
+	Row     string  // Row is the row name
+	Col     string  // Col is the column name
+	A       any     // A is the value in the first table
+	B       any     // B is the value in the second table
+	AbsDiff float64 // AbsDiff is |A-B|. NaN if A or B is not numeric.
+	RelDiff float64 // RelDiff is |A-B| / max(|A|, |B|). NaN if A or B is not numeric.
+}
+
+func (c *CellDiff) String() string {
+	return fmt.Sprintf("row %s, column %s: %s -> %s", c.Row, c.Col, PrettyString(c.A), PrettyString(c.B))
+}
+
+// TableDiff holds the differences between two tables. See DiffTables.
+type TableDiff struct {
+	AddedRows   []string    // AddedRows are in the second table but not the first
+	RemovedRows []string    // RemovedRows are in the first table but not the second
+	AddedCols   []string    // AddedCols are in the second table but not the first
+	RemovedCols []string    // RemovedCols are in the first table but not the second
+	Cells       []*CellDiff // Cells are the cells common to both tables that differ
+	Tol         float64     // Tol is the tolerance used
+
+	a, b         *Table
+	aRows, bRows map[string]int // positions of the row names of a and b
+	aCols, bCols map[string]int // positions of the columns of Data of a and b
+}
+
+// DiffTables compares tables a and b. Rows are matched by RowNames and columns by ColNames.
+// Numeric cells differ if either the absolute or the relative difference exceeds tol. Other cells
+// differ if they print differently with RawString or one is nil and the other is not.
+func DiffTables(a, b *Table, tol float64) (*TableDiff, error) {
+	if e := a.check(); e != nil {
+		return nil, fmt.Errorf("a: %v: DiffTables", e)
+	}
+
+	if e := b.check(); e != nil {
+		return nil, fmt.Errorf("b: %v: DiffTables", e)
+	}
+
+	if tol < 0 {
+		return nil, fmt.Errorf("tol must be non-negative: DiffTables")
+	}
+
+	td := &TableDiff{Tol: tol, a: a, b: b,
+		aRows: nameIndex(a.RowNames), bRows: nameIndex(b.RowNames),
+		aCols: nameIndex(dataNames(a)), bCols: nameIndex(dataNames(b)),
+	}
+
+	if len(td.aRows) != len(a.RowNames) || len(td.bRows) != len(b.RowNames) {
+		return nil, fmt.Errorf("row names must be unique: DiffTables")
+	}
+
+	td.RemovedRows, td.AddedRows = missingNames(a.RowNames, td.bRows), missingNames(b.RowNames, td.aRows)
+	td.RemovedCols, td.AddedCols = missingNames(dataNames(a), td.bCols), missingNames(dataNames(b), td.aCols)
+
+	for aCol, colName := range dataNames(a) {
+		bCol, ok := td.bCols[colName]
+		if !ok {
+			continue
+		}
+
+		for aRow, rowName := range a.RowNames {
+			bRow, ok := td.bRows[rowName]
+			if !ok {
+				continue
+			}
+
+			if cd := diffCell(a.Data[aCol][aRow], b.Data[bCol][bRow], tol); cd != nil {
+				cd.Row, cd.Col = rowName, colName
+				td.Cells = append(td.Cells, cd)
+			}
+		}
+	}
+
+	return td, nil
+}
+
+// nameIndex maps each name to its position
+func nameIndex(names []string) map[string]int {
+	out := make(map[string]int)
+	for ind, name := range names {
+		out[name] = ind
+	}
+
+	return out
+}
+
+// missingNames returns the elements of names not in index
+func missingNames(names []string, index map[string]int) []string {
+	var out []string
+	for _, name := range names {
+		if _, ok := index[name]; !ok {
+			out = append(out, name)
+		}
+	}
+
+	return out
+}
+
+// dataNames returns the names of the columns of Data
+func dataNames(tbl *Table) []string {
+	if len(tbl.ColNames) == 0 {
+		return nil
+	}
+
+	return tbl.ColNames[1:]
+}
+
+// diffCell returns a CellDiff if x and y differ, otherwise nil
+func diffCell(x, y any, tol float64) *CellDiff {
+	cd := &CellDiff{A: x, B: y, AbsDiff: math.NaN(), RelDiff: math.NaN()}
+
+	xf, xNum := numberOf(x)
+	yf, yNum := numberOf(y)
+	if !xNum || !yNum {
+		if x == nil && y == nil {
+			return nil
+		}
+
+		if x != nil && y != nil && RawString(x) == RawString(y) {
+			return nil
+		}
+
+		return cd
+	}
+
+	// NaN equals NaN here
+	if xf == yf || (math.IsNaN(xf) && math.IsNaN(yf)) {
+		return nil
+	}
+
+	cd.AbsDiff = math.Abs(xf - yf)
+	cd.RelDiff = 0
+	if scale := math.Max(math.Abs(xf), math.Abs(yf)); scale > 0 {
+		cd.RelDiff = cd.AbsDiff / scale
+	}
+
+	if math.IsNaN(cd.AbsDiff) || cd.AbsDiff > tol || cd.RelDiff > tol {
+		return cd
+	}
+
+	return nil
+}
+
+// Equal returns true if the tables have the same rows and columns and no cells differ
+func (td *TableDiff) Equal() bool {
+	return len(td.AddedRows)+len(td.RemovedRows)+len(td.AddedCols)+len(td.RemovedCols)+len(td.Cells) == 0
+}
+
+// Err returns nil if the tables are equal, otherwise an error describing the differences.
+// It is intended for tests, e.g. assert.Nil(t, diff.Err()).
+func (td *TableDiff) Err() error {
+	const maxCells = 10
+
+	if td.Equal() {
+		return nil
+	}
+
+	var msgs []string
+	addNames := func(what string, names []string) {
+		if len(names) > 0 {
+			msgs = append(msgs, fmt.Sprintf("%s: %s", what, strings.Join(names, ", ")))
+		}
+	}
+
+	addNames("added rows", td.AddedRows)
+	addNames("removed rows", td.RemovedRows)
+	addNames("added columns", td.AddedCols)
+	addNames("removed columns", td.RemovedCols)
+
+	if len(td.Cells) > 0 {
+		msgs = append(msgs, fmt.Sprintf("%d cells differ (tol %v)", len(td.Cells), td.Tol))
+	}
+
+	for ind, cd := range td.Cells {
+		if ind == maxCells {
+			msgs = append(msgs, "...")
+			break
+		}
+
+		msgs = append(msgs, cd.String())
+	}
+
+	return fmt.Errorf("tables differ:\n%s", strings.Join(msgs, "\n"))
+}
+
+// Table returns the differences as a Table covering the rows and columns of both tables. Changed cells show
+// "a → b", added rows and columns are prefixed with "+" and removed ones with "-".
+func (td *TableDiff) Table() *Table {
+	changed := make(map[[2]string]*CellDiff)
+	for _, cd := range td.Cells {
+		changed[[2]string{cd.Row, cd.Col}] = cd
+	}
+
+	rows := append(append([]string{}, td.a.RowNames...), td.AddedRows...)
+	cols := append(append([]string{}, dataNames(td.a)...), td.AddedCols...)
+
+	// names in a but not b are removed, names in b but not a are added
+	mark := func(name string, aIndex, bIndex map[string]int) string {
+		_, inA := aIndex[name]
+		_, inB := bIndex[name]
+		switch {
+		case !inA:
+			return "+" + name
+		case !inB:
+			return "-" + name
+		}
+
+		return name
+	}
+
+	out := &Table{ColNames: []string{""}, Data: make([][]any, len(cols))}
+	if len(td.a.ColNames) > 0 {
+		out.ColNames[0] = td.a.ColNames[0]
+	}
+
+	for _, row := range rows {
+		out.RowNames = append(out.RowNames, mark(row, td.aRows, td.bRows))
+	}
+
+	for col, colName := range cols {
+		out.ColNames = append(out.ColNames, mark(colName, td.aCols, td.bCols))
+		_, colInA := td.aCols[colName]
+		for _, row := range rows {
+			_, rowInA := td.aRows[row]
+
+			var str string
+			switch cd, ok := changed[[2]string{row, colName}]; {
+			case ok:
+				str = PrettyString(cd.A) + " → " + PrettyString(cd.B)
+			case rowInA && colInA:
+				str = tableValue(td.a, td.aRows, td.aCols, row, colName)
+			default:
+				str = tableValue(td.b, td.bRows, td.bCols, row, colName)
+			}
+
+			out.Data[col] = append(out.Data[col], str)
+		}
+	}
+
+	return out
+}
+
+// tableValue returns the printed value of tbl at row, col, "" if there is none. rows and cols are the
+// nameIndex of the RowNames and the columns of Data of tbl.
+func tableValue(tbl *Table, rows, cols map[string]int, row, col string) string {
+	rowInd, okRow := rows[row]
+	colInd, okCol := cols[col]
+	if !okRow || !okCol {
+		return ""
+	}
+
+	return tbl.cell(colInd, rowInd, false)
+}
+
+func (td *TableDiff) String() string {
+	return td.Table().String()
+}
@@ -0,0 +1,244 @@
+package utilities
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TableFormat is the layout used to render a Table
+type TableFormat int
+
+const (
+	TablePlain TableFormat = 0 + iota
+	TableMarkdown
+	TableCSV
+	TableTSV
+	TableJSONRecords // JSON array with one object per row
+	TableJSONColumns // JSON object with one array per column
+	TableHTML
+	TableLaTeX
+)
+
+func (tf TableFormat) String() string {
+	switch tf {
+	case TablePlain:
+		return "plain"
+	case TableMarkdown:
+		return "markdown"
+	case TableCSV:
+		return "csv"
+	case TableTSV:
+		return "tsv"
+	case TableJSONRecords:
+		return "json records"
+	case TableJSONColumns:
+		return "json columns"
+	case TableHTML:
+		return "html"
+	case TableLaTeX:
+		return "latex"
+	}
+
+	return ""
+}
+
+// WriteFormat writes the table to outFile in the layout given by format.
+// If raw, the values are written as-is rather than passed through PrettyString.
+func (cd *Table) WriteFormat(outFile string, format TableFormat, raw bool) error {
+	str, err := cd.Render(format, raw)
+	if err != nil {
+		return err
+	}
+
+	return ToFile(outFile, str)
+}
+
+// Render returns the table in the layout given by format.
+// If raw, the values are rendered as-is rather than passed through PrettyString.
+func (cd *Table) Render(format TableFormat, raw bool) (string, error) {
+	if e := cd.check(); e != nil {
+		return "", fmt.Errorf("%v: Render", e)
+	}
+
+	if format.String() == "" {
+		return "", fmt.Errorf("unknown table format %d: Render", format)
+	}
+
+	// plain and markdown tables with no columns are empty
+	if len(cd.Data) == 0 && (format == TablePlain || format == TableMarkdown) {
+		return "", nil
+	}
+
+	var sb strings.Builder
+	if e := cd.Stream(&sb, format, raw); e != nil {
+		return "", e
+	}
+
+	return sb.String(), nil
+}
+
+// nRows returns the number of rows in the table. A table with no Data columns has a row for each RowName.
+func (cd *Table) nRows() int {
+	if len(cd.Data) == 0 {
+		return len(cd.RowNames)
+	}
+
+	return len(cd.Data[0])
+}
+
+// cell returns the string version of the element at col, row of Data
+func (cd *Table) cell(col, row int, raw bool) string {
+	return formatCell(cd.Data[col][row], cd.format(col), raw)
+}
+
+// formatCell returns the string version of x. If raw, this is RawString, otherwise cf is used if it is
+// not nil and PrettyString if it is.
+func formatCell(x any, cf *ColFormat, raw bool) string {
+	if raw {
+		return RawString(x)
+	}
+
+	if cf != nil {
+		return cf.Format(x)
+	}
+
+	return PrettyString(x)
+}
+
+// format returns the entry of Formats for column col of Data, nil if there is none
+func (cd *Table) format(col int) *ColFormat {
+	if len(cd.Formats) == 0 || col+1 >= len(cd.ColNames) {
+		return nil
+	}
+
+	return cd.Formats[cd.ColNames[col+1]]
+}
+
+// RawString returns a string version of x with full precision and no thousands separators.
+// Times that are not midnight are in RFC 3339 format.
+func RawString(x any) string {
+	switch val := x.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(val), 'f', -1, 32)
+	case time.Time:
+		// dates are written without a time of day, other times in full
+		if h, m, sec := val.Clock(); h == 0 && m == 0 && sec == 0 && val.Nanosecond() == 0 {
+			return val.Format("2006-01-02")
+		}
+
+		return val.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+// isNumeric returns true if every non-nil element of col is an int or float and at least one is present
+func isNumeric(col []any) bool {
+	found := false
+	for _, x := range col {
+		switch x.(type) {
+		case nil:
+			continue
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			found = true
+		default:
+			return false
+		}
+	}
+
+	return found
+}
+
+// numberOf returns x as a float64 if x is an int, uint or float of any size
+func numberOf(x any) (float64, bool) {
+	val := reflect.ValueOf(x)
+	switch {
+	case val.CanInt():
+		return float64(val.Int()), true
+	case val.CanUint():
+		return float64(val.Uint()), true
+	case val.CanFloat():
+		return val.Float(), true
+	}
+
+	return 0, false
+}
+
+// jsonCell returns the value to marshal for x. NaN and Inf become null. If not raw, this is formatCell.
+func jsonCell(x any, cf *ColFormat, raw bool) any {
+	if !raw {
+		return formatCell(x, cf, raw)
+	}
+
+	switch val := x.(type) {
+	case time.Time:
+		return RawString(val)
+	case float64:
+		if math.IsNaN(val) || math.IsInf(val, 0) {
+			return nil
+		}
+	case float32:
+		if math.IsNaN(float64(val)) || math.IsInf(float64(val), 0) {
+			return nil
+		}
+	}
+
+	return x
+}
+
+// rowKey returns the name of the RowNames column, whose heading is header, in JSON and ClickHouse output.
+// This is header, or "row" if header is empty.
+func rowKey(header string) string {
+	if header != "" {
+		return header
+	}
+
+	return "row"
+}
+
+// writeJSONPair writes "key": val to w
+func writeJSONPair(w io.Writer, key string, val any) error {
+	k, e := json.Marshal(key)
+	if e != nil {
+		return e
+	}
+
+	v, e := json.Marshal(val)
+	if e != nil {
+		return e
+	}
+
+	_, e = fmt.Fprintf(w, "%s: %s", k, v)
+
+	return e
+}
+
+// latexEscape escapes the LaTeX special characters in inStr
+func latexEscape(inStr string) string {
+	repl := strings.NewReplacer(
+		`\`, `\textbackslash{}`,
+		"&", `\&`,
+		"%", `\%`,
+		"$", `\$`,
+		"#", `\#`,
+		"_", `\_`,
+		"{", `\{`,
+		"}", `\}`,
+		"~", `\textasciitilde{}`,
+		"^", `\textasciicircum{}`,
+	)
+
+	return repl.Replace(inStr)
+}
@@ -0,0 +1,490 @@
+package utilities
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// group is a set of rows sharing the same key values
+type group struct {
+	keys []any // values of the key columns
+	rows []int // rows in the group
+}
+
+// groupRows groups the rows of the table by the values of keyCols. The groups are sorted by key values,
+// with nil last.
+func (cd *Table) groupRows(keyCols []string) ([]*group, error) {
+	var keyVals [][]any
+	for _, key := range keyCols {
+		vals, e := cd.column(key)
+		if e != nil {
+			return nil, e
+		}
+
+		keyVals = append(keyVals, vals)
+	}
+
+	var groups []*group
+	lookup := make(map[string]*group)
+	for row := 0; row < len(cd.RowNames); row++ {
+		keys := make([]any, len(keyVals))
+		strs := make([]string, len(keyVals))
+		for ind, vals := range keyVals {
+			keys[ind] = vals[row]
+			// grouped by joinKey so numbers of different types that are equal share a group, as in Join
+			strs[ind] = joinKey(vals[row])
+		}
+
+		lbl := strings.Join(strs, "\x00")
+		grp, ok := lookup[lbl]
+		if !ok {
+			grp = &group{keys: keys}
+			lookup[lbl] = grp
+			groups = append(groups, grp)
+		}
+
+		grp.rows = append(grp.rows, row)
+	}
+
+	var err error
+	sort.SliceStable(groups, func(i, j int) bool {
+		for ind := range keyCols {
+			x, y := groups[i].keys[ind], groups[j].keys[ind]
+			switch {
+			case x == nil && y == nil:
+				continue
+			case x == nil:
+				return false
+			case y == nil:
+				return true
+			}
+
+			lt, e := lessAny(x, y)
+			if e != nil {
+				err = e
+				return false
+			}
+
+			if lt {
+				return true
+			}
+
+			if gt, _ := lessAny(y, x); gt {
+				return false
+			}
+		}
+
+		return false
+	})
+
+	return groups, err
+}
+
+// aggregate returns the aggregate agg of the non-missing (see IsNA) elements of vals. The aggregates are:
+//   - count: number of non-missing values (int64)
+//   - sum: int64 if all values are integers, otherwise float64
+//   - mean, median: float64
+//   - min, max: same type as vals. Strings and dates are allowed.
+//
+// If there are no non-missing values, all aggregates other than count return nil.
+func aggregate(vals []any, agg string) (any, error) {
+	var nonNil []any
+	for _, x := range vals {
+		if !IsNA(x) {
+			nonNil = append(nonNil, x)
+		}
+	}
+
+	if agg == "count" {
+		return int64(len(nonNil)), nil
+	}
+
+	if len(nonNil) == 0 {
+		switch agg {
+		case "sum", "mean", "median", "min", "max":
+			return nil, nil
+		}
+	}
+
+	switch agg {
+	case "sum":
+		return sumAny(nonNil)
+	case "mean":
+		xs, e := float64s(nonNil)
+		if e != nil {
+			return nil, e
+		}
+
+		total := 0.0
+		for _, x := range xs {
+			total += x
+		}
+
+		return total / float64(len(xs)), nil
+	case "median":
+		xs, e := float64s(nonNil)
+		if e != nil {
+			return nil, e
+		}
+
+		sort.Float64s(xs)
+		mid := len(xs) / 2
+		if len(xs)%2 == 1 {
+			return xs[mid], nil
+		}
+
+		return (xs[mid-1] + xs[mid]) / 2, nil
+	case "min", "max":
+		best := nonNil[0]
+		for _, x := range nonNil[1:] {
+			lt, e := lessAny(x, best)
+			if e != nil {
+				return nil, e
+			}
+
+			if agg == "max" {
+				if lt, e = lessAny(best, x); e != nil {
+					return nil, e
+				}
+			}
+
+			if lt {
+				best = x
+			}
+		}
+
+		return best, nil
+	}
+
+	return nil, fmt.Errorf("unknown aggregate %s", agg)
+}
+
+// sumAny returns the sum of vals. The sum is an int64 if all the vals are integers of any kind, otherwise a float64.
+func sumAny(vals []any) (any, error) {
+	allInt := true
+	var (
+		intSum int64
+		fltSum float64
+	)
+
+	for _, x := range vals {
+		val := reflect.ValueOf(x)
+		switch {
+		case val.CanInt():
+			intSum += val.Int()
+			fltSum += float64(val.Int())
+		case val.CanUint() && val.Uint() <= math.MaxInt64:
+			intSum += int64(val.Uint())
+			fltSum += float64(val.Uint())
+		default:
+			allInt = false
+			xf, e := float64Of(x)
+			if e != nil {
+				return nil, e
+			}
+
+			fltSum += xf
+		}
+	}
+
+	if allInt {
+		return intSum, nil
+	}
+
+	return fltSum, nil
+}
+
+// float64Of returns x as a float64. Numbers of any kind are converted by value and strings are parsed.
+func float64Of(x any) (float64, error) {
+	if xf, ok := numberOf(x); ok {
+		return xf, nil
+	}
+
+	xf, e := Any2Float64(x)
+	if e != nil {
+		return 0, e
+	}
+
+	return *xf, nil
+}
+
+// float64s returns vals converted by float64Of
+func float64s(vals []any) ([]float64, error) {
+	xs := make([]float64, len(vals))
+	for ind, x := range vals {
+		var e error
+		if xs[ind], e = float64Of(x); e != nil {
+			return nil, e
+		}
+	}
+
+	return xs, nil
+}
+
+// GroupBy returns a new table with one row for each distinct combination of the values of keys, sorted by the keys.
+// aggs maps column names to the aggregate to calculate for the column: count, sum, mean, min, max or median.
+// The aggregate columns are named <column>_<aggregate> and are in alphabetical order of the column names.
+// RowNames are the key values, separated by ", ". If there is more than one key, the key columns are also
+// included in Data.
+func (cd *Table) GroupBy(keys []string, aggs map[string]string) (*Table, error) {
+	if e := cd.check(); e != nil {
+		return nil, fmt.Errorf("%v: GroupBy", e)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keys: GroupBy")
+	}
+
+	groups, err := cd.groupRows(keys)
+	if err != nil {
+		return nil, fmt.Errorf("%v: GroupBy", err)
+	}
+
+	var aggCols []string
+	for col := range aggs {
+		aggCols = append(aggCols, col)
+	}
+	sort.Strings(aggCols)
+
+	out := &Table{ColNames: []string{strings.Join(keys, ", ")}}
+	if len(keys) > 1 {
+		out.ColNames = append(out.ColNames, keys...)
+		out.Data = make([][]any, len(keys))
+	}
+
+	for _, grp := range groups {
+		var lbls []string
+		for ind, key := range grp.keys {
+			lbls = append(lbls, RawString(key))
+			if len(keys) > 1 {
+				out.Data[ind] = append(out.Data[ind], key)
+			}
+		}
+
+		out.RowNames = append(out.RowNames, strings.Join(lbls, ", "))
+	}
+
+	for _, col := range aggCols {
+		vals, e := cd.column(col)
+		if e != nil {
+			return nil, fmt.Errorf("%v: GroupBy", e)
+		}
+
+		outCol := make([]any, len(groups))
+		for ind, grp := range groups {
+			grpVals := make([]any, len(grp.rows))
+			for indRow, row := range grp.rows {
+				grpVals[indRow] = vals[row]
+			}
+
+			if outCol[ind], e = aggregate(grpVals, aggs[col]); e != nil {
+				return nil, fmt.Errorf("column %s: %v: GroupBy", col, e)
+			}
+		}
+
+		out.ColNames = append(out.ColNames, col+"_"+aggs[col])
+		out.Data = append(out.Data, outCol)
+	}
+
+	return out, nil
+}
+
+// Pivot returns a cross-tab of valueCol. The levels of rowKey are the RowNames and the levels of colKey are the
+// columns. Each cell is the aggregate agg (see GroupBy) of valueCol over the rows with those levels.
+// Cells with no rows are nil, so they print as blanks.
+func (cd *Table) Pivot(rowKey, colKey, valueCol, agg string) (*Table, error) {
+	if e := cd.check(); e != nil {
+		return nil, fmt.Errorf("%v: Pivot", e)
+	}
+
+	rowGroups, err := cd.groupRows([]string{rowKey})
+	if err != nil {
+		return nil, fmt.Errorf("%v: Pivot", err)
+	}
+
+	colGroups, err := cd.groupRows([]string{colKey})
+	if err != nil {
+		return nil, fmt.Errorf("%v: Pivot", err)
+	}
+
+	vals, err := cd.column(valueCol)
+	if err != nil {
+		return nil, fmt.Errorf("%v: Pivot", err)
+	}
+
+	// rowLevel[row] is the index into rowGroups of row
+	rowLevel := make([]int, len(cd.RowNames))
+	out := &Table{ColNames: []string{rowKey}}
+	for ind, grp := range rowGroups {
+		out.RowNames = append(out.RowNames, RawString(grp.keys[0]))
+		for _, row := range grp.rows {
+			rowLevel[row] = ind
+		}
+	}
+
+	for _, colGrp := range colGroups {
+		cells := make([][]any, len(rowGroups))
+		for _, row := range colGrp.rows {
+			cells[rowLevel[row]] = append(cells[rowLevel[row]], vals[row])
+		}
+
+		outCol := make([]any, len(rowGroups))
+		for ind, cell := range cells {
+			if cell == nil {
+				continue
+			}
+
+			var e error
+			if outCol[ind], e = aggregate(cell, agg); e != nil {
+				return nil, fmt.Errorf("%v: Pivot", e)
+			}
+		}
+
+		out.ColNames = append(out.ColNames, RawString(colGrp.keys[0]))
+		out.Data = append(out.Data, outCol)
+	}
+
+	return out, nil
+}
+
+// totals returns the aggregates aggs (see GroupBy) of the rows of the table. If aggs is nil, the numeric
+// columns are summed. Columns not in aggs are nil.
+func (cd *Table) totals(rows []int, aggs map[string]string) ([]any, error) {
+	if aggs == nil {
+		aggs = make(map[string]string)
+		for col := 0; col < len(cd.Data); col++ {
+			if isNumeric(cd.Data[col]) {
+				aggs[cd.ColNames[col+1]] = "sum"
+			}
+		}
+	}
+
+	out := make([]any, len(cd.Data))
+	for colName, agg := range aggs {
+		col, e := cd.colIndex(colName)
+		if e != nil {
+			return nil, e
+		}
+
+		if col < 0 {
+			return nil, fmt.Errorf("cannot aggregate the row name column %s", colName)
+		}
+
+		vals := make([]any, len(rows))
+		for ind, row := range rows {
+			vals[ind] = cd.Data[col][row]
+		}
+
+		if out[col], e = aggregate(vals, agg); e != nil {
+			return nil, fmt.Errorf("column %s: %v", colName, e)
+		}
+	}
+
+	return out, nil
+}
+
+// AddTotalRow appends a row named label that holds the aggregates aggs (see GroupBy) of each column.
+// If aggs is nil, the numeric columns are summed. Sums of integers remain integers.
+// Columns not in aggs are nil.
+func (cd *Table) AddTotalRow(label string, aggs map[string]string) error {
+	if e := cd.check(); e != nil {
+		return fmt.Errorf("%v: AddTotalRow", e)
+	}
+
+	tots, err := cd.totals(cd.allRows(), aggs)
+	if err != nil {
+		return fmt.Errorf("%v: AddTotalRow", err)
+	}
+
+	cd.RowNames = append(cd.RowNames, label)
+	for col := 0; col < len(cd.Data); col++ {
+		cd.Data[col] = append(cd.Data[col], tots[col])
+	}
+
+	return nil
+}
+
+// AddSubtotals groups the rows by the values of groupCol, sorted, and adds a row after each group that holds
+// the aggregates aggs (see AddTotalRow) of the group. The row is named "<level> subtotal", and groupCol holds the
+// level. Within a group, rows keep their order.
+func (cd *Table) AddSubtotals(groupCol string, aggs map[string]string) error {
+	if e := cd.check(); e != nil {
+		return fmt.Errorf("%v: AddSubtotals", e)
+	}
+
+	grpInd, err := cd.colIndex(groupCol)
+	if err != nil {
+		return fmt.Errorf("%v: AddSubtotals", err)
+	}
+
+	groups, err := cd.groupRows([]string{groupCol})
+	if err != nil {
+		return fmt.Errorf("%v: AddSubtotals", err)
+	}
+
+	out := &Table{Data: make([][]any, len(cd.Data))}
+	for _, grp := range groups {
+		tots, e := cd.totals(grp.rows, aggs)
+		if e != nil {
+			return fmt.Errorf("%v: AddSubtotals", e)
+		}
+
+		if grpInd >= 0 {
+			tots[grpInd] = grp.keys[0]
+		}
+
+		for _, row := range grp.rows {
+			out.RowNames = append(out.RowNames, cd.RowNames[row])
+			for col := 0; col < len(cd.Data); col++ {
+				out.Data[col] = append(out.Data[col], cd.Data[col][row])
+			}
+		}
+
+		out.RowNames = append(out.RowNames, RawString(grp.keys[0])+" subtotal")
+		for col := 0; col < len(cd.Data); col++ {
+			out.Data[col] = append(out.Data[col], tots[col])
+		}
+	}
+
+	cd.RowNames, cd.Data = out.RowNames, out.Data
+
+	return nil
+}
+
+// AddColumn appends a column named name. Its value in each row is fn(row), where row maps the column names,
+// including ColNames[0], to that row's values.
+func (cd *Table) AddColumn(name string, fn func(row map[string]any) any) error {
+	if e := cd.check(); e != nil {
+		return fmt.Errorf("%v: AddColumn", e)
+	}
+
+	if _, e := cd.colIndex(name); e == nil {
+		return fmt.Errorf("column %s already exists: AddColumn", name)
+	}
+
+	newCol := make([]any, len(cd.RowNames))
+	for row := 0; row < len(cd.RowNames); row++ {
+		vals := make(map[string]any)
+		if len(cd.ColNames) > 0 {
+			vals[cd.ColNames[0]] = cd.RowNames[row]
+		}
+
+		for col := 0; col < len(cd.Data); col++ {
+			vals[cd.ColNames[col+1]] = cd.Data[col][row]
+		}
+
+		newCol[row] = fn(vals)
+	}
+
+	if len(cd.ColNames) == 0 {
+		cd.ColNames = []string{""}
+	}
+
+	cd.ColNames = append(cd.ColNames, name)
+	cd.Data = append(cd.Data, newCol)
+
+	return nil
+}
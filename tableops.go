@@ -0,0 +1,568 @@
+package utilities
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// check returns an error if the dimensions of RowNames, ColNames and Data do not agree
+func (cd *Table) check() error {
+	if len(cd.Data) == 0 {
+		return nil
+	}
+
+	if len(cd.ColNames) != len(cd.Data)+1 {
+		return fmt.Errorf("table has %d columns of data but %d column names (need %d)",
+			len(cd.Data), len(cd.ColNames), len(cd.Data)+1)
+	}
+
+	for col := 0; col < len(cd.Data); col++ {
+		if len(cd.Data[col]) != len(cd.RowNames) {
+			return fmt.Errorf("column %s has %d rows but there are %d row names",
+				cd.ColNames[col+1], len(cd.Data[col]), len(cd.RowNames))
+		}
+	}
+
+	return nil
+}
+
+// colIndex returns the index into Data of column colName. It returns -1 if colName is ColNames[0], the
+// RowNames column.
+func (cd *Table) colIndex(colName string) (int, error) {
+	for ind, name := range cd.ColNames {
+		if name == colName {
+			return ind - 1, nil
+		}
+	}
+
+	return 0, fmt.Errorf("column %s not in table", colName)
+}
+
+// column returns the values of column colName, which may be the RowNames column
+func (cd *Table) column(colName string) ([]any, error) {
+	col, err := cd.colIndex(colName)
+	if err != nil {
+		return nil, err
+	}
+
+	if col >= 0 {
+		return cd.Data[col], nil
+	}
+
+	out := make([]any, len(cd.RowNames))
+	for ind, rn := range cd.RowNames {
+		out[ind] = rn
+	}
+
+	return out, nil
+}
+
+// subset returns a new table with the rows and the columns of Data given by rows, cols
+func (cd *Table) subset(rows, cols []int) *Table {
+	out := &Table{ColNames: []string{""}, RowNames: make([]string, len(rows)), Data: make([][]any, len(cols))}
+	if len(cd.ColNames) > 0 {
+		out.ColNames[0] = cd.ColNames[0]
+	}
+
+	for ind, row := range rows {
+		out.RowNames[ind] = cd.RowNames[row]
+	}
+
+	for ind, col := range cols {
+		out.ColNames = append(out.ColNames, cd.ColNames[col+1])
+		out.Data[ind] = make([]any, len(rows))
+		for indRow, row := range rows {
+			out.Data[ind][indRow] = cd.Data[col][row]
+		}
+	}
+
+	if cd.Formats != nil {
+		out.Formats = make(map[string]*ColFormat)
+		for k, v := range cd.Formats {
+			out.Formats[k] = v
+		}
+	}
+
+	return out
+}
+
+// allCols returns the indices of all the columns of Data
+func (cd *Table) allCols() []int {
+	cols := make([]int, len(cd.Data))
+	for ind := range cols {
+		cols[ind] = ind
+	}
+
+	return cols
+}
+
+// allRows returns the indices of all the rows
+func (cd *Table) allRows() []int {
+	rows := make([]int, len(cd.RowNames))
+	for ind := range rows {
+		rows[ind] = ind
+	}
+
+	return rows
+}
+
+// lessAny returns x < y. Values of different numeric types are compared as float64, otherwise
+// the comparison is done by LTAny or, failing that, Comparer.
+func lessAny(x, y any) (bool, error) {
+	if reflect.TypeOf(x) == reflect.TypeOf(y) {
+		if lt, e := LTAny(x, y); e == nil {
+			return lt, nil
+		}
+	}
+
+	if isNumeric([]any{x, y}) {
+		xf, _ := numberOf(x)
+		yf, _ := numberOf(y)
+
+		return xf < yf, nil
+	}
+
+	return Comparer(x, y, "<")
+}
+
+// SortBy returns a new table sorted on column col, which may be the RowNames column. The sort is stable.
+func (cd *Table) SortBy(col string, descending bool) (*Table, error) {
+	return cd.SortByKeys([]string{col}, []bool{descending})
+}
+
+// SortByKeys returns a new table sorted on the columns cols. Ties on cols[0] are broken by cols[1], etc.
+// descending[ind] gives the direction of cols[ind]. nil values sort last. The sort is stable.
+func (cd *Table) SortByKeys(cols []string, descending []bool) (*Table, error) {
+	if e := cd.check(); e != nil {
+		return nil, fmt.Errorf("%v: SortByKeys", e)
+	}
+
+	if len(cols) != len(descending) {
+		return nil, fmt.Errorf("cols and descending must be the same length: SortByKeys")
+	}
+
+	var keys [][]any
+	for _, col := range cols {
+		vals, e := cd.column(col)
+		if e != nil {
+			return nil, fmt.Errorf("%v: SortByKeys", e)
+		}
+
+		keys = append(keys, vals)
+	}
+
+	var err error
+	rows := cd.allRows()
+	sort.SliceStable(rows, func(i, j int) bool {
+		for ind, key := range keys {
+			x, y := key[rows[i]], key[rows[j]]
+			switch {
+			case x == nil && y == nil:
+				continue
+			case x == nil:
+				return false
+			case y == nil:
+				return true
+			}
+
+			lt, e := lessAny(x, y)
+			if e != nil {
+				err = e
+				return false
+			}
+
+			gt, e := lessAny(y, x)
+			if e != nil {
+				err = e
+				return false
+			}
+
+			if lt == gt {
+				continue
+			}
+
+			return lt != descending[ind]
+		}
+
+		return false
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("%v: SortByKeys", err)
+	}
+
+	return cd.subset(rows, cd.allCols()), nil
+}
+
+// Filter returns a new table with the rows for which "col op value" is true. col may be the RowNames column.
+// The operators are those of Comparer: ==, !=, >, <, >=, <=. Rows where col is nil are dropped.
+func (cd *Table) Filter(col, op string, value any) (*Table, error) {
+	if e := cd.check(); e != nil {
+		return nil, fmt.Errorf("%v: Filter", e)
+	}
+
+	vals, err := cd.column(col)
+	if err != nil {
+		return nil, fmt.Errorf("%v: Filter", err)
+	}
+
+	var rows []int
+	for row, x := range vals {
+		if x == nil {
+			continue
+		}
+
+		keep, e := filterCompare(x, value, op)
+		if e != nil {
+			return nil, fmt.Errorf("%v: Filter", e)
+		}
+
+		if keep {
+			rows = append(rows, row)
+		}
+	}
+
+	return cd.subset(rows, cd.allCols()), nil
+}
+
+// filterCompare returns "x op value". Numbers of any kind are compared by value, otherwise by Comparer.
+func filterCompare(x, value any, op string) (bool, error) {
+	xf, xNum := numberOf(x)
+	vf, vNum := numberOf(value)
+	if !xNum || !vNum {
+		return Comparer(x, value, op)
+	}
+
+	switch op {
+	case ">":
+		return xf > vf, nil
+	case ">=":
+		return xf >= vf, nil
+	case "==":
+		return xf == vf, nil
+	case "!=":
+		return xf != vf, nil
+	case "<":
+		return xf < vf, nil
+	case "<=":
+		return xf <= vf, nil
+	}
+
+	return false, fmt.Errorf("unsupported comparison: %s", op)
+}
+
+// Select returns a new table with the columns cols, in that order. The RowNames column is always included.
+func (cd *Table) Select(cols ...string) (*Table, error) {
+	if e := cd.check(); e != nil {
+		return nil, fmt.Errorf("%v: Select", e)
+	}
+
+	var inds []int
+	for _, col := range cols {
+		ind, e := cd.colIndex(col)
+		if e != nil {
+			return nil, fmt.Errorf("%v: Select", e)
+		}
+
+		if ind >= 0 {
+			inds = append(inds, ind)
+		}
+	}
+
+	return cd.subset(cd.allRows(), inds), nil
+}
+
+// Drop returns a new table without the columns cols. The RowNames column cannot be dropped.
+func (cd *Table) Drop(cols ...string) (*Table, error) {
+	if e := cd.check(); e != nil {
+		return nil, fmt.Errorf("%v: Drop", e)
+	}
+
+	drop := make(map[int]bool)
+	for _, col := range cols {
+		ind, e := cd.colIndex(col)
+		if e != nil {
+			return nil, fmt.Errorf("%v: Drop", e)
+		}
+
+		if ind < 0 {
+			return nil, fmt.Errorf("cannot drop the row name column %s: Drop", col)
+		}
+
+		drop[ind] = true
+	}
+
+	var inds []int
+	for col := 0; col < len(cd.Data); col++ {
+		if !drop[col] {
+			inds = append(inds, col)
+		}
+	}
+
+	return cd.subset(cd.allRows(), inds), nil
+}
+
+// Transpose returns a new table whose rows are the columns of the table. ColNames[0] is retained.
+func (cd *Table) Transpose() (*Table, error) {
+	if e := cd.check(); e != nil {
+		return nil, fmt.Errorf("%v: Transpose", e)
+	}
+
+	out := &Table{ColNames: []string{""}, Data: make([][]any, len(cd.RowNames))}
+	if len(cd.ColNames) > 0 {
+		out.ColNames[0] = cd.ColNames[0]
+		out.RowNames = append(out.RowNames, cd.ColNames[1:]...)
+	}
+
+	out.ColNames = append(out.ColNames, cd.RowNames...)
+	for row := 0; row < len(cd.RowNames); row++ {
+		out.Data[row] = make([]any, len(cd.Data))
+		for col := 0; col < len(cd.Data); col++ {
+			out.Data[row][col] = cd.Data[col][row]
+		}
+	}
+
+	return out, nil
+}
+
+// AppendRows returns a new table with the rows of other after the rows of the table. Columns are matched
+// by name, so other must have the same columns, though not necessarily in the same order.
+func (cd *Table) AppendRows(other *Table) (*Table, error) {
+	if e := cd.check(); e != nil {
+		return nil, fmt.Errorf("%v: AppendRows", e)
+	}
+
+	if e := other.check(); e != nil {
+		return nil, fmt.Errorf("other: %v: AppendRows", e)
+	}
+
+	if len(cd.Data) != len(other.Data) {
+		return nil, fmt.Errorf("tables have %d and %d columns: AppendRows", len(cd.Data), len(other.Data))
+	}
+
+	out := cd.subset(cd.allRows(), cd.allCols())
+	out.RowNames = append(out.RowNames, other.RowNames...)
+
+	for col := 0; col < len(cd.Data); col++ {
+		otherCol, e := other.colIndex(cd.ColNames[col+1])
+		if e != nil || otherCol < 0 {
+			return nil, fmt.Errorf("column %s not in other: AppendRows", cd.ColNames[col+1])
+		}
+
+		out.Data[col] = append(out.Data[col], other.Data[otherCol]...)
+	}
+
+	return out, nil
+}
+
+// AppendCols returns a new table with the columns of other after the columns of the table. The tables must
+// have the same RowNames, in the same order, and no column names in common.
+func (cd *Table) AppendCols(other *Table) (*Table, error) {
+	if e := cd.check(); e != nil {
+		return nil, fmt.Errorf("%v: AppendCols", e)
+	}
+
+	if e := other.check(); e != nil {
+		return nil, fmt.Errorf("other: %v: AppendCols", e)
+	}
+
+	if len(cd.RowNames) != len(other.RowNames) {
+		return nil, fmt.Errorf("tables have %d and %d rows: AppendCols", len(cd.RowNames), len(other.RowNames))
+	}
+
+	for row, rn := range cd.RowNames {
+		if other.RowNames[row] != rn {
+			return nil, fmt.Errorf("row %d is %s in the table but %s in other: AppendCols", row, rn, other.RowNames[row])
+		}
+	}
+
+	out := cd.subset(cd.allRows(), cd.allCols())
+	for col := 0; col < len(other.Data); col++ {
+		name := other.ColNames[col+1]
+		if _, e := cd.colIndex(name); e == nil {
+			return nil, fmt.Errorf("column %s is in both tables: AppendCols", name)
+		}
+
+		out.ColNames = append(out.ColNames, name)
+		out.Data = append(out.Data, append([]any{}, other.Data[col]...))
+		if cf := other.format(col); cf != nil {
+			if out.Formats == nil {
+				out.Formats = make(map[string]*ColFormat)
+			}
+
+			out.Formats[name] = cf
+		}
+	}
+
+	return out, nil
+}
+
+// joinKeys returns the join key of each row of the table. If on is "" or the RowNames column, the keys are
+// the RowNames. Also returned is the index into Data of on (-1 for RowNames).
+func (cd *Table) joinKeys(on string) ([]string, int, error) {
+	col := -1
+	if on != "" {
+		var e error
+		if col, e = cd.colIndex(on); e != nil {
+			return nil, 0, e
+		}
+	}
+
+	keys := make([]string, len(cd.RowNames))
+	for row := range keys {
+		var x any = cd.RowNames[row]
+		if col >= 0 {
+			x = cd.Data[col][row]
+		}
+
+		keys[row] = joinKey(x)
+	}
+
+	return keys, col, nil
+}
+
+// joinKey returns the key of x for Join. Numbers of any type are equal if their values are, so int64(3),
+// int(3) and 3.0 match. Other values match if they have the same type and RawString.
+func joinKey(x any) string {
+	val := reflect.ValueOf(x)
+	switch {
+	case val.CanInt():
+		return "number:" + strconv.FormatInt(val.Int(), 10)
+	case val.CanUint():
+		return "number:" + strconv.FormatUint(val.Uint(), 10)
+	case val.CanFloat():
+		// whole numbers are written as ints so they match int keys
+		if f := val.Float(); f == math.Trunc(f) && math.Abs(f) < 1<<63 {
+			return "number:" + strconv.FormatInt(int64(f), 10)
+		}
+
+		return "number:" + strconv.FormatFloat(val.Float(), 'g', -1, 64)
+	}
+
+	return fmt.Sprintf("%T:%s", x, RawString(x))
+}
+
+// Join returns a new table that joins the table and other on the column on, which may be "" to join on RowNames.
+// kind is one of:
+//   - inner: rows whose key is in both tables
+//   - left: all the rows of the table
+//   - outer: all the rows of both tables
+//
+// The columns of other, other than on, follow those of the table and must have different names. Rows with more
+// than one match are repeated for each match. Missing values are nil. Numeric keys match by value, whatever
+// their types.
+func (cd *Table) Join(other *Table, on, kind string) (*Table, error) {
+	if kind != "inner" && kind != "left" && kind != "outer" {
+		return nil, fmt.Errorf("kind must be inner, left or outer, got %s: Join", kind)
+	}
+
+	if e := cd.check(); e != nil {
+		return nil, fmt.Errorf("%v: Join", e)
+	}
+
+	if e := other.check(); e != nil {
+		return nil, fmt.Errorf("other: %v: Join", e)
+	}
+
+	leftKeys, leftOn, err := cd.joinKeys(on)
+	if err != nil {
+		return nil, fmt.Errorf("%v: Join", err)
+	}
+
+	rightKeys, rightOn, err := other.joinKeys(on)
+	if err != nil {
+		return nil, fmt.Errorf("other: %v: Join", err)
+	}
+
+	// columns of other to include
+	var rightCols []int
+	for col := 0; col < len(other.Data); col++ {
+		if col == rightOn {
+			continue
+		}
+
+		if _, e := cd.colIndex(other.ColNames[col+1]); e == nil {
+			return nil, fmt.Errorf("column %s is in both tables: Join", other.ColNames[col+1])
+		}
+
+		rightCols = append(rightCols, col)
+	}
+
+	rightRows := make(map[string][]int)
+	for row, key := range rightKeys {
+		rightRows[key] = append(rightRows[key], row)
+	}
+
+	out := cd.subset(nil, cd.allCols())
+	for _, col := range rightCols {
+		out.ColNames = append(out.ColNames, other.ColNames[col+1])
+		out.Data = append(out.Data, nil)
+	}
+
+	// addRow appends a row from left row leftRow and right row rightRow. Either may be -1 for a missing row.
+	addRow := func(leftRow, rightRow int) {
+		var rowName string
+		switch {
+		case leftRow >= 0:
+			rowName = cd.RowNames[leftRow]
+		case leftOn < 0 && rightOn >= 0:
+			rowName = RawString(other.Data[rightOn][rightRow])
+		default:
+			rowName = other.RowNames[rightRow]
+		}
+
+		out.RowNames = append(out.RowNames, rowName)
+		for col := 0; col < len(cd.Data); col++ {
+			var x any
+			switch {
+			case leftRow >= 0:
+				x = cd.Data[col][leftRow]
+			case col == leftOn && rightOn >= 0:
+				x = other.Data[rightOn][rightRow]
+			case col == leftOn:
+				x = other.RowNames[rightRow]
+			}
+
+			out.Data[col] = append(out.Data[col], x)
+		}
+
+		for ind, col := range rightCols {
+			var x any
+			if rightRow >= 0 {
+				x = other.Data[col][rightRow]
+			}
+
+			out.Data[len(cd.Data)+ind] = append(out.Data[len(cd.Data)+ind], x)
+		}
+	}
+
+	matched := make(map[string]bool)
+	for leftRow, key := range leftKeys {
+		rows, ok := rightRows[key]
+		if !ok {
+			if kind != "inner" {
+				addRow(leftRow, -1)
+			}
+
+			continue
+		}
+
+		matched[key] = true
+		for _, rightRow := range rows {
+			addRow(leftRow, rightRow)
+		}
+	}
+
+	if kind == "outer" {
+		for rightRow, key := range rightKeys {
+			if !matched[key] {
+				addRow(-1, rightRow)
+			}
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,93 @@
+package utilities
+
+import (
+	"fmt"
+
+	grob "github.com/MetalBlueberry/go-plotly/graph_objects"
+)
+
+// ToPlotlyTable returns a Plotly figure with a table trace of the table. The values are formatted as the
+// table prints them. The figure may be passed to Plotter. Leave XTitle and YTitle of the PlotDef empty,
+// otherwise empty axes are drawn.
+func (cd *Table) ToPlotlyTable() (*grob.Fig, error) {
+	if e := cd.check(); e != nil {
+		return nil, fmt.Errorf("%v: ToPlotlyTable", e)
+	}
+
+	values := [][]string{cd.RowNames}
+	for col := 0; col < len(cd.Data); col++ {
+		vals := make([]string, cd.nRows())
+		for row := 0; row < cd.nRows(); row++ {
+			vals[row] = cd.cell(col, row, false)
+		}
+
+		values = append(values, vals)
+	}
+
+	tr := &grob.Table{
+		Type: grob.TraceTypeTable,
+		Header: &grob.TableHeader{
+			Values: cd.ColNames,
+			Align:  grob.TableHeaderAlignCenter,
+			Font:   &grob.TableHeaderFont{Color: "white"},
+			Fill:   &grob.TableHeaderFill{Color: "grey"},
+		},
+		Cells: &grob.TableCells{
+			Values: values,
+			Align:  grob.TableCellsAlignLeft,
+		},
+	}
+
+	return &grob.Fig{Data: grob.Traces{tr}}, nil
+}
+
+// ToHeatmap returns a Plotly figure with a heatmap of the table, which must be all numeric. The first row is
+// at the top. Missing values (see IsNA) are gaps. The figure may be passed to Plotter.
+func (cd *Table) ToHeatmap() (*grob.Fig, error) {
+	if e := cd.check(); e != nil {
+		return nil, fmt.Errorf("%v: ToHeatmap", e)
+	}
+
+	if len(cd.Data) == 0 {
+		return nil, fmt.Errorf("no columns: ToHeatmap")
+	}
+
+	for col := 0; col < len(cd.Data); col++ {
+		if !isNumeric(cd.Data[col]) {
+			return nil, fmt.Errorf("column %s is not numeric: ToHeatmap", cd.ColNames[col+1])
+		}
+	}
+
+	// Plotly puts the first y at the bottom, so the rows are reversed
+	var (
+		y    []string
+		z    [][]any
+		text [][]string
+	)
+
+	for row := cd.nRows() - 1; row >= 0; row-- {
+		zRow := make([]any, len(cd.Data))
+		textRow := make([]string, len(cd.Data))
+		for col := 0; col < len(cd.Data); col++ {
+			textRow[col] = cd.cell(col, row, false)
+			if x := cd.Data[col][row]; !IsNA(x) {
+				zRow[col], _ = numberOf(x)
+			}
+		}
+
+		y = append(y, cd.RowNames[row])
+		z = append(z, zRow)
+		text = append(text, textRow)
+	}
+
+	tr := &grob.Heatmap{
+		Type:          grob.TraceTypeHeatmap,
+		X:             cd.ColNames[1:],
+		Y:             y,
+		Z:             z,
+		Text:          text,
+		Hovertemplate: "%{y}, %{x}: %{text}<extra></extra>",
+	}
+
+	return &grob.Fig{Data: grob.Traces{tr}}, nil
+}
@@ -0,0 +1,354 @@
+package utilities
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ReadTable reads a Table from inFile, which is in the layout given by format.
+// Supported layouts are TablePlain, TableMarkdown, TableCSV and TableTSV. See ParseTable.
+func ReadTable(inFile string, format TableFormat) (*Table, error) {
+	handle, err := os.Open(inFile)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = handle.Close() }()
+
+	return ParseTable(handle, format)
+}
+
+// ParseTable reads a Table from rdr, which is in the layout given by format.
+// The first row supplies ColNames and the first column supplies RowNames.
+// Each column of Data is typed as int64, float64, time.Time or string -- the first of these that every non-empty
+// entry can be converted to. Empty entries are nil.
+//
+// TablePlain expects the output of Table.String(): columns are separated by at least two spaces. A row with an
+// empty row name and all elements missing prints as a blank line, so it is lost if it is the first or last row.
+func ParseTable(rdr io.Reader, format TableFormat) (*Table, error) {
+	var (
+		cells [][]string
+		err   error
+	)
+
+	switch format {
+	case TableCSV:
+		cells, err = parseDelimited(rdr, ',')
+	case TableTSV:
+		cells, err = parseDelimited(rdr, '\t')
+	case TablePlain:
+		cells, err = parsePlain(rdr)
+	case TableMarkdown:
+		cells, err = parseMarkdown(rdr)
+	default:
+		return nil, fmt.Errorf("cannot read table format %v: ParseTable", format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tableFromCells(cells)
+}
+
+// tableFromCells builds a Table from cells, which are stored by rows. The first row is the header.
+func tableFromCells(cells [][]string) (*Table, error) {
+	if len(cells) == 0 {
+		return nil, fmt.Errorf("no header row: ParseTable")
+	}
+
+	nCol := len(cells[0])
+	if nCol < 2 {
+		return nil, fmt.Errorf("table needs a row name column and at least one data column: ParseTable")
+	}
+
+	tbl := &Table{ColNames: cells[0], Data: make([][]any, nCol-1)}
+
+	for row := 1; row < len(cells); row++ {
+		if len(cells[row]) != nCol {
+			return nil, fmt.Errorf("row %d has %d columns, expected %d: ParseTable", row, len(cells[row]), nCol)
+		}
+
+		tbl.RowNames = append(tbl.RowNames, cells[row][0])
+	}
+
+	for col := 1; col < nCol; col++ {
+		vals := make([]string, len(cells)-1)
+		for row := 1; row < len(cells); row++ {
+			vals[row-1] = cells[row][col]
+		}
+
+		tbl.Data[col-1] = inferColumn(vals)
+	}
+
+	return tbl, nil
+}
+
+// thousands matches integers with comma thousands separators, as produced by PrettyString
+var thousands = regexp.MustCompile(`^-?\d{1,3}(,\d{3})+(\.\d*)?$`)
+
+// inferColumn converts vals to the first of int64, float64, time.Time, string that fits every non-empty value.
+func inferColumn(vals []string) []any {
+	number := func(str string) string {
+		if thousands.MatchString(str) {
+			return strings.ReplaceAll(str, ",", "")
+		}
+
+		return str
+	}
+
+	converters := []func(str string) (any, bool){
+		func(str string) (any, bool) {
+			x, e := Any2Int64(number(str))
+			return x, e == nil
+		},
+		func(str string) (any, bool) {
+			x, e := Any2Float64(number(str))
+			return x, e == nil
+		},
+		func(str string) (any, bool) {
+			x, e := Any2Date(str)
+			return x, e == nil
+		},
+	}
+
+	out := make([]any, len(vals))
+	for _, conv := range converters {
+		ok := true
+		for ind, str := range vals {
+			if str == "" {
+				out[ind] = nil
+				continue
+			}
+
+			var x any
+			if x, ok = conv(str); !ok {
+				break
+			}
+
+			// converters return pointers
+			switch v := x.(type) {
+			case *int64:
+				out[ind] = *v
+			case *float64:
+				out[ind] = *v
+			case *time.Time:
+				out[ind] = *v
+			}
+		}
+
+		if ok {
+			return out
+		}
+	}
+
+	for ind, str := range vals {
+		out[ind] = nil
+		if str != "" {
+			out[ind] = str
+		}
+	}
+
+	return out
+}
+
+// parseDelimited reads delimited text with separator sep
+func parseDelimited(rdr io.Reader, sep rune) ([][]string, error) {
+	csvRdr := csv.NewReader(rdr)
+	csvRdr.Comma = sep
+	csvRdr.LazyQuotes = sep == '\t'
+
+	return csvRdr.ReadAll()
+}
+
+// readLines returns the lines of rdr with trailing white space removed. Leading and trailing blank lines are
+// dropped. Blank lines inside the table are kept, since they are rows whose elements are all missing.
+func readLines(rdr io.Reader) ([]string, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(rdr)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" && len(lines) == 0 {
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines, scanner.Err()
+}
+
+// parsePlain reads the output of Table.String(). Column boundaries are runs of at least two display columns
+// that are blank on every line, so empty cells and single spaces inside a cell are handled. Positions are
+// measured by RuneWidth, as Pad does, so wide characters do not shift the columns.
+func parsePlain(rdr io.Reader) ([][]string, error) {
+	const minGutter = 2
+
+	lines, err := readLines(rdr)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		dispLines [][]string
+		width     int
+	)
+
+	for _, line := range lines {
+		if !utf8.ValidString(line) {
+			return nil, fmt.Errorf("line is not valid UTF-8: ParseTable")
+		}
+
+		d := displayColumns(line)
+		dispLines = append(dispLines, d)
+		width = MaxInt(width, len(d))
+	}
+
+	blank := make([]bool, width+minGutter)
+	for pos := range blank {
+		blank[pos] = true
+		for _, d := range dispLines {
+			if pos < len(d) && d[pos] != " " {
+				blank[pos] = false
+				break
+			}
+		}
+	}
+
+	// find [start, end) of each column
+	var starts, ends []int
+	for pos := 0; pos < len(blank); {
+		if blank[pos] {
+			pos++
+			continue
+		}
+
+		start := pos
+		for pos < len(blank) {
+			gutter := 0
+			for pos+gutter < len(blank) && blank[pos+gutter] {
+				gutter++
+			}
+
+			if gutter >= minGutter {
+				break
+			}
+
+			pos += gutter + 1
+		}
+
+		starts, ends = append(starts, start), append(ends, pos)
+	}
+
+	cells := make([][]string, len(dispLines))
+	for row, d := range dispLines {
+		for col := range starts {
+			start, end := MinInt(starts[col], len(d)), MinInt(ends[col], len(d))
+			cells[row] = append(cells[row], strings.TrimSpace(strings.Join(d[start:end], "")))
+		}
+	}
+
+	return cells, nil
+}
+
+// displayColumns splits line into the text at each display column. A wide rune is followed by "" for the
+// column it spills into. Zero-width runes are attached to the preceding column.
+func displayColumns(line string) []string {
+	var cols []string
+	for _, r := range line {
+		switch w := RuneWidth(r); {
+		case w == 0 && len(cols) > 0:
+			cols[len(cols)-1] += string(r)
+		case w == 0:
+			cols = append(cols, string(r))
+		default:
+			cols = append(cols, string(r))
+			for ind := 1; ind < w; ind++ {
+				cols = append(cols, "")
+			}
+		}
+	}
+
+	return cols
+}
+
+// markdownSep matches the separator row of a Markdown table
+var markdownSep = regexp.MustCompile(`^[\s|:-]+$`)
+
+// parseMarkdown reads a Markdown table. Lines that do not start with "|" are ignored. The line after the header
+// must be the separator row.
+func parseMarkdown(rdr io.Reader) ([][]string, error) {
+	lines, err := readLines(rdr)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		cells    [][]string
+		sepFound bool
+	)
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "|") {
+			continue
+		}
+
+		// only the line after the header is the separator, so data rows of dashes or blanks are kept
+		if len(cells) == 1 && !sepFound {
+			if !markdownSep.MatchString(line) || !strings.Contains(line, "-") {
+				return nil, fmt.Errorf("no separator row after the header: ParseTable")
+			}
+
+			sepFound = true
+			continue
+		}
+
+		cells = append(cells, splitPipes(line))
+	}
+
+	if len(cells) == 1 && !sepFound {
+		return nil, fmt.Errorf("no separator row after the header: ParseTable")
+	}
+
+	return cells, nil
+}
+
+// splitPipes splits a Markdown table line on unescaped pipes and unescapes the cells
+func splitPipes(line string) []string {
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	var (
+		out  []string
+		cell strings.Builder
+	)
+
+	for ind := 0; ind < len(line); ind++ {
+		switch {
+		case line[ind] == '\\' && ind+1 < len(line) && line[ind+1] == '|':
+			cell.WriteByte('|')
+			ind++
+		case line[ind] == '|':
+			out = append(out, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[ind])
+		}
+	}
+
+	return append(out, strings.TrimSpace(cell.String()))
+}
@@ -0,0 +1,345 @@
+package utilities
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// structField is an exported struct field included in a Table
+type structField struct {
+	index  int    // index of the field in the struct
+	name   string // column name
+	format string // format spec (see ParseColFormat), "" if none
+}
+
+// structFields returns the fields of the struct type t that are included in a Table. The column name and format
+// come from a `table:"name,format"` tag. Fields tagged `table:"-"` are skipped.
+func structFields(t reflect.Type) ([]structField, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected a struct, got %v", t)
+	}
+
+	var fields []structField
+	for ind := 0; ind < t.NumField(); ind++ {
+		fld := t.Field(ind)
+		if !fld.IsExported() {
+			continue
+		}
+
+		tag := fld.Tag.Get("table")
+		if tag == "-" {
+			continue
+		}
+
+		name, format, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = fld.Name
+		}
+
+		fields = append(fields, structField{index: ind, name: name, format: format})
+	}
+
+	return fields, nil
+}
+
+// TableFromStructs builds a Table from rows. Each exported field of T is a column.
+// The column name and format (see ParseColFormat) are taken from a `table:"name,format"` tag, if present.
+// Fields tagged `table:"-"` are skipped.
+// If rowNameField is not "", the field with that name (or tag name) supplies RowNames, otherwise the
+// rows are numbered starting at 1.
+func TableFromStructs[T any](rows []T, rowNameField string) (*Table, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	isPtr := t.Kind() == reflect.Pointer
+	if isPtr {
+		t = t.Elem()
+	}
+
+	fields, err := structFields(t)
+	if err != nil {
+		return nil, fmt.Errorf("%v: TableFromStructs", err)
+	}
+
+	rowField := -1
+	tbl := &Table{ColNames: []string{rowNameField}}
+	for ind, fld := range fields {
+		if rowNameField != "" && (fld.name == rowNameField || t.Field(fld.index).Name == rowNameField) {
+			rowField = ind
+			tbl.ColNames[0] = fld.name
+			continue
+		}
+
+		tbl.ColNames = append(tbl.ColNames, fld.name)
+		tbl.Data = append(tbl.Data, make([]any, 0, len(rows)))
+
+		if fld.format != "" {
+			cf, e := ParseColFormat(fld.format)
+			if e != nil {
+				return nil, fmt.Errorf("field %s: %v: TableFromStructs", fld.name, e)
+			}
+
+			if tbl.Formats == nil {
+				tbl.Formats = make(map[string]*ColFormat)
+			}
+
+			tbl.Formats[fld.name] = cf
+		}
+	}
+
+	if rowNameField != "" && rowField < 0 {
+		return nil, fmt.Errorf("field %s not found: TableFromStructs", rowNameField)
+	}
+
+	for row := 0; row < len(rows); row++ {
+		val := reflect.ValueOf(rows[row])
+		if isPtr {
+			if val.IsNil() {
+				return nil, fmt.Errorf("row %d is nil: TableFromStructs", row)
+			}
+
+			val = val.Elem()
+		}
+
+		tbl.RowNames = append(tbl.RowNames, fmt.Sprintf("%d", row+1))
+
+		col := 0
+		for ind, fld := range fields {
+			x := fieldValue(val.Field(fld.index))
+			if ind == rowField {
+				tbl.RowNames[row] = RawString(x)
+				continue
+			}
+
+			tbl.Data[col] = append(tbl.Data[col], x)
+			col++
+		}
+	}
+
+	return tbl, nil
+}
+
+// fieldValue returns the value of fld, dereferencing pointers. A nil pointer returns nil.
+func fieldValue(fld reflect.Value) any {
+	for fld.Kind() == reflect.Pointer {
+		if fld.IsNil() {
+			return nil
+		}
+
+		fld = fld.Elem()
+	}
+
+	return fld.Interface()
+}
+
+// ToStructs populates dest, which must be a pointer to a slice of structs, with the rows of the table.
+// Columns are matched to fields as in TableFromStructs. Values are converted as needed (see setField).
+// The RowNames column populates the field named ColNames[0], if there is one.
+// nil values leave the field at its zero value.
+func (cd *Table) ToStructs(dest any) error {
+	if e := cd.check(); e != nil {
+		return fmt.Errorf("%v: ToStructs", e)
+	}
+
+	ptr := reflect.ValueOf(dest)
+	if ptr.Kind() != reflect.Pointer || ptr.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("dest must be a pointer to a slice: ToStructs")
+	}
+
+	slc := ptr.Elem()
+	elemType := slc.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Pointer
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+
+	fields, err := structFields(elemType)
+	if err != nil {
+		return fmt.Errorf("%v: ToStructs", err)
+	}
+
+	// column of Data for each field, -1 for RowNames, -2 if not in the table
+	cols := make([]int, len(fields))
+	for ind, fld := range fields {
+		cols[ind] = -2
+		if len(cd.ColNames) > 0 && cd.ColNames[0] != "" && fld.name == cd.ColNames[0] {
+			cols[ind] = -1
+			continue
+		}
+
+		for col := 1; col < len(cd.ColNames); col++ {
+			if cd.ColNames[col] == fld.name {
+				cols[ind] = col - 1
+			}
+		}
+	}
+
+	out := reflect.MakeSlice(slc.Type(), 0, len(cd.RowNames))
+	for row := 0; row < len(cd.RowNames); row++ {
+		elem := reflect.New(elemType).Elem()
+		for ind, fld := range fields {
+			var x any
+			switch col := cols[ind]; {
+			case col == -2:
+				continue
+			case col == -1:
+				x = cd.RowNames[row]
+			default:
+				x = cd.Data[col][row]
+			}
+
+			if e := setField(elem.Field(fld.index), x); e != nil {
+				return fmt.Errorf("row %d, column %s: %v: ToStructs", row, fld.name, e)
+			}
+		}
+
+		if isPtr {
+			elem = elem.Addr()
+		}
+
+		out = reflect.Append(out, elem)
+	}
+
+	slc.Set(out)
+
+	return nil
+}
+
+// setField sets fld to x. If x is not of the kind of fld, numeric fields are set by setNumber, so fractional or
+// overflowing values are errors, bool fields are parsed by ParseBool and others are converted with Any2Kind.
+func setField(fld reflect.Value, x any) error {
+	if x == nil {
+		return nil
+	}
+
+	target := fld
+	if fld.Kind() == reflect.Pointer {
+		target = reflect.New(fld.Type().Elem()).Elem()
+	}
+
+	val := reflect.ValueOf(x)
+	switch {
+	case val.Type().ConvertibleTo(target.Type()) && val.Kind() == target.Kind():
+		target.Set(val.Convert(target.Type()))
+	case target.Kind() == reflect.Bool:
+		b, e := ParseBool(Any2String(x), nil)
+		if e != nil {
+			return e
+		}
+
+		target.SetBool(b)
+	case target.CanInt() || target.CanUint() || target.CanFloat():
+		if e := setNumber(target, x); e != nil {
+			return e
+		}
+	default:
+		conv, e := Any2Kind(x, target.Kind())
+		if e != nil {
+			return e
+		}
+
+		if val = reflect.ValueOf(conv); !val.Type().ConvertibleTo(target.Type()) {
+			return fmt.Errorf("cannot assign %v to %v", val.Type(), target.Type())
+		}
+
+		target.Set(val.Convert(target.Type()))
+	}
+
+	if fld.Kind() == reflect.Pointer {
+		fld.Set(target.Addr())
+	}
+
+	return nil
+}
+
+// setNumber sets the int, uint or float target to x, which is a number of any kind or a string.
+// It returns an error if x does not fit in target.
+func setNumber(target reflect.Value, x any) error {
+	val := reflect.ValueOf(x)
+	str, isStr := x.(string)
+	str = strings.TrimSpace(str)
+
+	switch {
+	case target.CanInt():
+		var (
+			xi int64
+			e  error
+		)
+
+		switch {
+		case isStr:
+			xi, e = strconv.ParseInt(str, 10, 64)
+		case val.CanInt():
+			xi = val.Int()
+		case val.CanUint() && val.Uint() <= math.MaxInt64:
+			xi = int64(val.Uint())
+		case val.CanFloat() && val.Float() == math.Trunc(val.Float()) && math.Abs(val.Float()) < 1<<63:
+			xi = int64(val.Float())
+		default:
+			e = fmt.Errorf("cannot convert %v to %v", x, target.Type())
+		}
+
+		if e != nil {
+			return e
+		}
+
+		if target.OverflowInt(xi) {
+			return fmt.Errorf("%v overflows %v", x, target.Type())
+		}
+
+		target.SetInt(xi)
+	case target.CanUint():
+		var (
+			xu uint64
+			e  error
+		)
+
+		switch {
+		case isStr:
+			xu, e = strconv.ParseUint(str, 10, 64)
+		case val.CanUint():
+			xu = val.Uint()
+		case val.CanInt() && val.Int() >= 0:
+			xu = uint64(val.Int())
+		case val.CanFloat() && val.Float() == math.Trunc(val.Float()) && val.Float() >= 0 && val.Float() < 1<<64:
+			xu = uint64(val.Float())
+		default:
+			e = fmt.Errorf("cannot convert %v to %v", x, target.Type())
+		}
+
+		if e != nil {
+			return e
+		}
+
+		if target.OverflowUint(xu) {
+			return fmt.Errorf("%v overflows %v", x, target.Type())
+		}
+
+		target.SetUint(xu)
+	case target.CanFloat():
+		xf, ok := numberOf(x)
+		if isStr {
+			var e error
+			if xf, e = strconv.ParseFloat(str, 64); e != nil {
+				return e
+			}
+
+			ok = true
+		}
+
+		if !ok {
+			return fmt.Errorf("cannot convert %v to %v", x, target.Type())
+		}
+
+		if target.OverflowFloat(xf) {
+			return fmt.Errorf("%v overflows %v", x, target.Type())
+		}
+
+		target.SetFloat(xf)
+	default:
+		return fmt.Errorf("%v is not a number", target.Type())
+	}
+
+	return nil
+}
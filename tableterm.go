@@ -0,0 +1,189 @@
+package utilities
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// TermOptions controls how a Table is rendered by Table.Terminal
+type TermOptions struct {
+	Width   int  // Width is the terminal width. If 0, it is detected; if that fails, 80 is used.
+	MaxCell int  // MaxCell is the maximum display width of a cell. Longer cells end with "…". 0 means no limit.
+	Box     bool // Box draws Unicode box borders
+	Bold    bool // Bold prints the header row in bold
+}
+
+// Terminal writes the table to out. If out is a terminal, the table is fit to its width: columns that do not
+// fit are moved to additional pages, each of which repeats the RowNames column. Otherwise, the output is
+// the same as String(). opts may be nil.
+func (cd *Table) Terminal(out io.Writer, opts *TermOptions) error {
+	if e := cd.check(); e != nil {
+		return fmt.Errorf("%v: Terminal", e)
+	}
+
+	fl, ok := out.(*os.File)
+	if !ok || !term.IsTerminal(int(fl.Fd())) {
+		_, err := io.WriteString(out, cd.String())
+		return err
+	}
+
+	tOpts := TermOptions{}
+	if opts != nil {
+		tOpts = *opts
+	}
+
+	if tOpts.Width == 0 {
+		if width, _, e := term.GetSize(int(fl.Fd())); e == nil {
+			tOpts.Width = width
+		}
+	}
+
+	_, err := io.WriteString(out, cd.TermString(&tOpts))
+
+	return err
+}
+
+// TermString returns the table laid out for a terminal as described by opts. See Terminal. opts may be nil.
+// The result is "" if the dimensions of the table do not agree.
+func (cd *Table) TermString(opts *TermOptions) string {
+	const (
+		defaultWidth = 80
+		padLength    = 4
+		ellipsis     = "…"
+	)
+
+	if len(cd.Data) == 0 || cd.check() != nil {
+		return ""
+	}
+
+	tOpts := TermOptions{}
+	if opts != nil {
+		tOpts = *opts
+	}
+
+	if tOpts.Width <= 0 {
+		tOpts.Width = defaultWidth
+	}
+
+	// cells is stored by rows
+	cells := [][]string{append([]string{}, cd.ColNames...)}
+	for row := 0; row < cd.nRows(); row++ {
+		line := []string{cd.RowNames[row]}
+		for col := 0; col < len(cd.Data); col++ {
+			line = append(line, cd.cell(col, row, false))
+		}
+
+		cells = append(cells, line)
+	}
+
+	widths := make([]int, len(cells[0]))
+	for row := range cells {
+		for col := range cells[row] {
+			if tOpts.MaxCell > 0 && DisplayWidth(cells[row][col]) > tOpts.MaxCell {
+				cells[row][col] = Truncate(cells[row][col], tOpts.MaxCell, ellipsis)
+			}
+
+			widths[col] = MaxInt(widths[col], DisplayWidth(cells[row][col]))
+		}
+	}
+
+	// overhead is the display width a column adds beyond its contents
+	overhead := padLength
+	if tOpts.Box {
+		overhead = 3
+	}
+
+	// each page holds the columns of Data that fit next to the RowNames column
+	var pages [][]int
+	used := 0
+	for col := 1; col < len(widths); col++ {
+		if len(pages) == 0 || used+widths[col]+overhead > tOpts.Width {
+			pages = append(pages, nil)
+			used = widths[0] + overhead
+			if tOpts.Box {
+				used++
+			}
+		}
+
+		pages[len(pages)-1] = append(pages[len(pages)-1], col)
+		used += widths[col] + overhead
+	}
+
+	align := cd.aligns()
+	var sb strings.Builder
+	for ind, page := range pages {
+		if ind > 0 {
+			sb.WriteString("\n")
+		}
+
+		cols := append([]int{0}, page...)
+		for row := range cells {
+			if tOpts.Box && row == 0 {
+				sb.WriteString(boxRule(cols, widths, "┌", "┬", "┐"))
+			}
+
+			for _, col := range cols {
+				cell := padCell(cells[row][col], widths[col], align[col])
+				if row == 0 && tOpts.Bold {
+					cell = "\x1b[1m" + cell + "\x1b[0m"
+				}
+
+				if tOpts.Box {
+					sb.WriteString("│ " + cell + " ")
+					continue
+				}
+
+				sb.WriteString(cell + strings.Repeat(" ", padLength))
+			}
+
+			if tOpts.Box {
+				sb.WriteString("│")
+			}
+			sb.WriteString("\n")
+
+			if tOpts.Box && row == 0 {
+				sb.WriteString(boxRule(cols, widths, "├", "┼", "┤"))
+			}
+		}
+
+		if tOpts.Box {
+			sb.WriteString(boxRule(cols, widths, "└", "┴", "┘"))
+		}
+	}
+
+	return sb.String()
+}
+
+// boxRule returns a horizontal box border for the columns cols
+func boxRule(cols, widths []int, left, mid, right string) string {
+	var parts []string
+	for _, col := range cols {
+		parts = append(parts, strings.Repeat("─", widths[col]+2))
+	}
+
+	return left + strings.Join(parts, mid) + right + "\n"
+}
+
+// Truncate shortens inStr so that, with tail appended, its DisplayWidth is at most maxWidth.
+// inStr is returned unchanged if it already fits.
+func Truncate(inStr string, maxWidth int, tail string) string {
+	if DisplayWidth(inStr) <= maxWidth {
+		return inStr
+	}
+
+	room := maxWidth - DisplayWidth(tail)
+	width := 0
+	for ind, r := range inStr {
+		if width+RuneWidth(r) > room {
+			return inStr[:ind] + tail
+		}
+
+		width += RuneWidth(r)
+	}
+
+	return inStr
+}
@@ -0,0 +1,353 @@
+package utilities
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// StreamOptions are the options for a TableWriter
+type StreamOptions struct {
+	Raw        bool                  // Raw writes values as-is rather than passed through PrettyString
+	Widths     []int                 // Widths are the widths of the columns, including RowNames, for plain and markdown
+	Align      []Align               // Align are the alignments of the columns, including RowNames. Default is left.
+	Formats    map[string]*ColFormat // Formats are optional formats by column name
+	BufferSize int                   // BufferSize is the size of the output buffer. Default is 64k.
+}
+
+// SampleOptions returns StreamOptions with the widths, alignments and formats of sample for writing format.
+// sample may be the first rows of a larger table: longer values in later rows are written in full, so their
+// columns will not line up.
+func SampleOptions(sample *Table, format TableFormat, raw bool) *StreamOptions {
+	cells := [][]string{sample.ColNames}
+	for row := 0; row < sample.nRows(); row++ {
+		line := []string{sample.RowNames[row]}
+		for col := 0; col < len(sample.Data); col++ {
+			line = append(line, sample.cell(col, row, raw))
+		}
+
+		cells = append(cells, line)
+	}
+
+	for row := 0; row < len(cells); row++ {
+		cells[row] = streamCells(cells[row], format)
+	}
+
+	return &StreamOptions{Raw: raw, Widths: colWidths(cells), Align: sample.aligns(), Formats: sample.Formats}
+}
+
+// TableWriter writes a table row by row to an io.Writer. Memory use does not depend on the number of rows,
+// except for TableJSONColumns, which must hold the table until Close.
+type TableWriter struct {
+	out      *bufio.Writer
+	csv      *csv.Writer
+	format   TableFormat
+	colNames []string
+	opts     StreamOptions
+
+	nRows    int
+	rowNames []string // TableJSONColumns only
+	columns  [][]any  // TableJSONColumns only
+}
+
+// NewTableWriter returns a TableWriter that writes a table with columns colNames to out in the layout given
+// by format. colNames[0] is the heading of the RowNames column. The header is written immediately.
+// Rows are added by WriteRow. Close must be called to complete the table.
+// If opts is nil, the columns of plain and markdown tables are the widths of colNames.
+func NewTableWriter(out io.Writer, format TableFormat, colNames []string, opts *StreamOptions) (*TableWriter, error) {
+	if format.String() == "" {
+		return nil, fmt.Errorf("unknown table format %d: NewTableWriter", format)
+	}
+
+	if len(colNames) == 0 {
+		return nil, fmt.Errorf("no columns: NewTableWriter")
+	}
+
+	tw := &TableWriter{format: format, colNames: colNames}
+	if opts != nil {
+		tw.opts = *opts
+	}
+
+	if tw.opts.Widths == nil {
+		tw.opts.Widths = colWidths([][]string{streamCells(colNames, format)})
+	}
+
+	if len(tw.opts.Widths) != len(colNames) {
+		return nil, fmt.Errorf("have %d widths for %d columns: NewTableWriter", len(tw.opts.Widths), len(colNames))
+	}
+
+	const defaultBuffer = 1 << 16
+	if tw.opts.BufferSize <= 0 {
+		tw.opts.BufferSize = defaultBuffer
+	}
+
+	tw.out = bufio.NewWriterSize(out, tw.opts.BufferSize)
+
+	if format == TableJSONColumns {
+		tw.rowNames, tw.columns = []string{}, make([][]any, len(colNames)-1)
+	}
+
+	if e := tw.header(); e != nil {
+		return nil, e
+	}
+
+	return tw, nil
+}
+
+// Stream writes the table to out in the layout given by format. If raw, the values are written as-is
+// rather than passed through PrettyString.
+func (cd *Table) Stream(out io.Writer, format TableFormat, raw bool) error {
+	if e := cd.check(); e != nil {
+		return fmt.Errorf("%v: Stream", e)
+	}
+
+	tw, e := NewTableWriter(out, format, cd.ColNames, SampleOptions(cd, format, raw))
+	if e != nil {
+		return e
+	}
+
+	vals := make([]any, len(cd.Data))
+	for row := 0; row < cd.nRows(); row++ {
+		for col := 0; col < len(cd.Data); col++ {
+			vals[col] = cd.Data[col][row]
+		}
+
+		if e := tw.WriteRow(cd.RowNames[row], vals...); e != nil {
+			return e
+		}
+	}
+
+	return tw.Close()
+}
+
+// WriteRow writes a row with row name rowName and values vals, one per column.
+func (tw *TableWriter) WriteRow(rowName string, vals ...any) error {
+	if len(vals) != len(tw.colNames)-1 {
+		return fmt.Errorf("have %d values for %d columns: WriteRow", len(vals), len(tw.colNames)-1)
+	}
+
+	defer func() { tw.nRows++ }()
+
+	if tw.format == TableJSONColumns {
+		tw.rowNames = append(tw.rowNames, rowName)
+		for col, x := range vals {
+			tw.columns[col] = append(tw.columns[col], jsonCell(x, tw.colFormat(col), tw.opts.Raw))
+		}
+
+		return nil
+	}
+
+	if tw.format == TableJSONRecords {
+		if tw.nRows > 0 {
+			_, _ = tw.out.WriteString(",")
+		}
+
+		_, _ = tw.out.WriteString("\n  {")
+		if e := writeJSONPair(tw.out, rowKey(tw.colNames[0]), rowName); e != nil {
+			return e
+		}
+
+		for col, x := range vals {
+			_, _ = tw.out.WriteString(", ")
+			if e := writeJSONPair(tw.out, tw.colNames[col+1], jsonCell(x, tw.colFormat(col), tw.opts.Raw)); e != nil {
+				return e
+			}
+		}
+
+		_, err := tw.out.WriteString("}")
+
+		return err
+	}
+
+	line := []string{rowName}
+	for col, x := range vals {
+		line = append(line, formatCell(x, tw.colFormat(col), tw.opts.Raw))
+	}
+
+	return tw.writeLine(line, "td")
+}
+
+// Close writes the end of the table and flushes the output. It does not close the underlying io.Writer.
+func (tw *TableWriter) Close() error {
+	switch tw.format {
+	case TableCSV, TableTSV:
+		tw.csv.Flush()
+		if e := tw.csv.Error(); e != nil {
+			return e
+		}
+	case TableJSONRecords:
+		_, _ = tw.out.WriteString("\n]\n")
+	case TableJSONColumns:
+		_, _ = tw.out.WriteString("{\n  ")
+		if e := writeJSONPair(tw.out, rowKey(tw.colNames[0]), tw.rowNames); e != nil {
+			return e
+		}
+
+		for col := 0; col < len(tw.columns); col++ {
+			vals := tw.columns[col]
+			if vals == nil {
+				vals = []any{}
+			}
+
+			_, _ = tw.out.WriteString(",\n  ")
+			if e := writeJSONPair(tw.out, tw.colNames[col+1], vals); e != nil {
+				return e
+			}
+		}
+
+		_, _ = tw.out.WriteString("\n}\n")
+	case TableHTML:
+		_, _ = tw.out.WriteString("</tbody>\n</table>\n")
+	case TableLaTeX:
+		_, _ = tw.out.WriteString("\\hline\n\\end{tabular}\n")
+	}
+
+	return tw.out.Flush()
+}
+
+// header writes the start of the table
+func (tw *TableWriter) header() error {
+	switch tw.format {
+	case TableCSV, TableTSV:
+		tw.csv = csv.NewWriter(tw.out)
+		if tw.format == TableTSV {
+			tw.csv.Comma = '\t'
+		}
+	case TableJSONRecords:
+		_, err := tw.out.WriteString("[")
+		return err
+	case TableJSONColumns:
+		return nil
+	case TableHTML:
+		_, _ = tw.out.WriteString("<table>\n<thead>\n<tr>")
+		for _, name := range tw.colNames {
+			_, _ = tw.out.WriteString("<th>" + html.EscapeString(name) + "</th>")
+		}
+
+		_, err := tw.out.WriteString("</tr>\n</thead>\n<tbody>\n")
+
+		return err
+	case TableLaTeX:
+		align := ""
+		for col := 0; col < len(tw.colNames); col++ {
+			if tw.align(col) == AlignRight {
+				align += "r"
+				continue
+			}
+
+			align += "l"
+		}
+
+		_, _ = tw.out.WriteString("\\begin{tabular}{" + align + "}\n\\hline\n")
+		_, _ = tw.out.WriteString(strings.Join(streamCells(tw.colNames, tw.format), " & ") + " \\\\\n")
+		_, err := tw.out.WriteString("\\hline\n")
+
+		return err
+	}
+
+	if e := tw.writeLine(tw.colNames, "th"); e != nil {
+		return e
+	}
+
+	if tw.format != TableMarkdown {
+		return nil
+	}
+
+	// the separator row needs at least three dashes
+	for col := 0; col < len(tw.colNames); col++ {
+		width := MaxInt(tw.opts.Widths[col], 3)
+		if tw.align(col) == AlignRight {
+			_, _ = tw.out.WriteString("| " + strings.Repeat("-", width-1) + ": ")
+			continue
+		}
+
+		_, _ = tw.out.WriteString("| :" + strings.Repeat("-", width-1) + " ")
+	}
+
+	_, err := tw.out.WriteString("|\n")
+
+	return err
+}
+
+// writeLine writes line, the header or a row, in the plain, markdown, delimited, HTML or LaTeX layout.
+// tag is the HTML tag for the values.
+func (tw *TableWriter) writeLine(line []string, tag string) error {
+	const padLength = 4
+
+	switch tw.format {
+	case TableCSV, TableTSV:
+		return tw.csv.Write(line)
+	case TableHTML:
+		_, _ = tw.out.WriteString("<tr><th>" + html.EscapeString(line[0]) + "</th>")
+		for _, c := range line[1:] {
+			_, _ = tw.out.WriteString("<" + tag + ">" + html.EscapeString(c) + "</" + tag + ">")
+		}
+
+		_, err := tw.out.WriteString("</tr>\n")
+
+		return err
+	case TableLaTeX:
+		_, err := tw.out.WriteString(strings.Join(streamCells(line, tw.format), " & ") + " \\\\\n")
+		return err
+	}
+
+	line = streamCells(line, tw.format)
+	for col, c := range line {
+		switch tw.format {
+		case TableMarkdown:
+			_, _ = tw.out.WriteString("| " + padCell(c, MaxInt(tw.opts.Widths[col], 3), tw.align(col)) + " ")
+		default:
+			_, _ = tw.out.WriteString(padCell(c, tw.opts.Widths[col], tw.align(col)) + strings.Repeat(" ", padLength))
+		}
+	}
+
+	end := "\n"
+	if tw.format == TableMarkdown {
+		end = "|\n"
+	}
+
+	_, err := tw.out.WriteString(end)
+
+	return err
+}
+
+// align returns the alignment of column col, including RowNames
+func (tw *TableWriter) align(col int) Align {
+	if col < len(tw.opts.Align) {
+		return tw.opts.Align[col]
+	}
+
+	return AlignLeft
+}
+
+// colFormat returns the format of value column col, nil if there is none
+func (tw *TableWriter) colFormat(col int) *ColFormat {
+	if len(tw.opts.Formats) == 0 {
+		return nil
+	}
+
+	return tw.opts.Formats[tw.colNames[col+1]]
+}
+
+// streamCells returns cells as they are written in format: trimmed for plain, pipes escaped for markdown
+// and LaTeX special characters escaped for LaTeX.
+func streamCells(cells []string, format TableFormat) []string {
+	out := make([]string, len(cells))
+	for ind, c := range cells {
+		switch format {
+		case TablePlain:
+			out[ind] = strings.Trim(c, " ")
+		case TableMarkdown:
+			out[ind] = strings.ReplaceAll(c, "|", `\|`)
+		case TableLaTeX:
+			out[ind] = latexEscape(c)
+		default:
+			out[ind] = c
+		}
+	}
+
+	return out
+}
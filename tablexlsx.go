@@ -0,0 +1,327 @@
+package utilities
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+)
+
+// Sheet is a Table to write to a named worksheet of a workbook
+type Sheet struct {
+	Name  string
+	Table *Table
+}
+
+// cell styles, these are indices into cellXfs of xlsxStyles
+const (
+	xlsxGeneral = 0 + iota
+	xlsxHeader
+	xlsxInt
+	xlsxDate
+)
+
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd"/></numFmts>
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="4">
+<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>
+<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>
+<xf numFmtId="3" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+</cellXfs>
+</styleSheet>
+`
+
+const xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>
+`
+
+// WriteXLSX writes the table to the worksheet sheet of the Excel workbook outFile.
+// See WriteWorkbook.
+func (cd *Table) WriteXLSX(outFile, sheet string) error {
+	return WriteWorkbook(outFile, Sheet{Name: sheet, Table: cd})
+}
+
+// WriteWorkbook writes each of sheets to its own worksheet of the Excel (.xlsx) workbook outFile.
+// Numbers and dates are written as typed cells, the header row is bold and the header row and RowNames
+// column are frozen. Column widths are those computed by Pad.
+func WriteWorkbook(outFile string, sheets ...Sheet) error {
+	if len(sheets) == 0 {
+		return fmt.Errorf("no sheets: WriteWorkbook")
+	}
+
+	seen := make(map[string]bool)
+	for _, sh := range sheets {
+		if e := checkSheetName(sh.Name); e != nil {
+			return fmt.Errorf("%v: WriteWorkbook", e)
+		}
+
+		// Excel sheet names are case-insensitive
+		if seen[strings.ToLower(sh.Name)] {
+			return fmt.Errorf("duplicate sheet name %s: WriteWorkbook", sh.Name)
+		}
+		seen[strings.ToLower(sh.Name)] = true
+
+		if sh.Table == nil {
+			return fmt.Errorf("sheet %s has no table: WriteWorkbook", sh.Name)
+		}
+
+		if e := sh.Table.check(); e != nil {
+			return fmt.Errorf("%v: WriteWorkbook", e)
+		}
+	}
+
+	handle, e := os.Create(outFile)
+	if e != nil {
+		return e
+	}
+
+	zw := zip.NewWriter(handle)
+
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes(len(sheets))},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", xlsxWorkbook(sheets)},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels(len(sheets))},
+		{"xl/styles.xml", xlsxStyles},
+	}
+
+	for ind, sh := range sheets {
+		parts = append(parts, struct{ name, body string }{fmt.Sprintf("xl/worksheets/sheet%d.xml", ind+1), sh.Table.xlsxSheet()})
+	}
+
+	for _, part := range parts {
+		w, e := zw.Create(part.name)
+		if e == nil {
+			_, e = w.Write([]byte(part.body))
+		}
+
+		if e != nil {
+			_ = zw.Close()
+			_ = handle.Close()
+			return e
+		}
+	}
+
+	if e := zw.Close(); e != nil {
+		_ = handle.Close()
+		return e
+	}
+
+	return handle.Close()
+}
+
+// checkSheetName returns an error if name is not a valid Excel worksheet name
+func checkSheetName(name string) error {
+	if name == "" || len([]rune(name)) > 31 {
+		return fmt.Errorf("sheet name %q must have 1 to 31 characters", name)
+	}
+
+	if strings.ContainsAny(name, `[]:*?/\`) {
+		return fmt.Errorf("sheet name %q may not contain any of []:*?/\\", name)
+	}
+
+	return nil
+}
+
+// xlsxContentTypes returns [Content_Types].xml for a workbook with nSheets sheets
+func xlsxContentTypes(nSheets int) string {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+`)
+
+	for ind := 1; ind <= nSheets; ind++ {
+		sb.WriteString(fmt.Sprintf(`<Override PartName="/xl/worksheets/sheet%d.xml" `+
+			`ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`+"\n", ind))
+	}
+
+	sb.WriteString("</Types>\n")
+
+	return sb.String()
+}
+
+// xlsxWorkbook returns xl/workbook.xml
+func xlsxWorkbook(sheets []Sheet) string {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets>
+`)
+
+	for ind, sh := range sheets {
+		sb.WriteString(fmt.Sprintf(`<sheet name="%s" sheetId="%d" r:id="rId%d"/>`+"\n", xmlEscape(sh.Name), ind+1, ind+1))
+	}
+
+	sb.WriteString("</sheets>\n</workbook>\n")
+
+	return sb.String()
+}
+
+// xlsxWorkbookRels returns xl/_rels/workbook.xml.rels. Sheets are rId1 to rId<nSheets>, styles follow.
+func xlsxWorkbookRels(nSheets int) string {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+`)
+
+	for ind := 1; ind <= nSheets; ind++ {
+		sb.WriteString(fmt.Sprintf(`<Relationship Id="rId%d" `+
+			`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" `+
+			`Target="worksheets/sheet%d.xml"/>`+"\n", ind, ind))
+	}
+
+	sb.WriteString(fmt.Sprintf(`<Relationship Id="rId%d" `+
+		`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`+"\n", nSheets+1))
+	sb.WriteString("</Relationships>\n")
+
+	return sb.String()
+}
+
+// xlsxSheet returns the worksheet XML for the table
+func (cd *Table) xlsxSheet() string {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<sheetViews><sheetView workbookViewId="0">` +
+		`<pane xSplit="1" ySplit="1" topLeftCell="B2" activePane="bottomRight" state="frozen"/>` +
+		`</sheetView></sheetViews>
+`)
+
+	sb.WriteString("<cols>")
+	for col, width := range cd.xlsxWidths() {
+		sb.WriteString(fmt.Sprintf(`<col min="%d" max="%d" width="%d" customWidth="1"/>`, col+1, col+1, width))
+	}
+	sb.WriteString("</cols>\n<sheetData>\n")
+
+	sb.WriteString(`<row r="1">`)
+	for col, name := range cd.ColNames {
+		sb.WriteString(xlsxString(xlsxRef(col, 1), name, xlsxHeader))
+	}
+	sb.WriteString("</row>\n")
+
+	for row := 0; row < cd.nRows(); row++ {
+		sb.WriteString(fmt.Sprintf(`<row r="%d">`, row+2))
+		sb.WriteString(xlsxString(xlsxRef(0, row+2), cd.RowNames[row], xlsxGeneral))
+
+		for col := 0; col < len(cd.Data); col++ {
+			sb.WriteString(xlsxCell(xlsxRef(col+1, row+2), cd.Data[col][row]))
+		}
+
+		sb.WriteString("</row>\n")
+	}
+
+	sb.WriteString("</sheetData>\n</worksheet>\n")
+
+	return sb.String()
+}
+
+// xlsxWidths returns the width of each column of the table, in characters. These are the widths Pad uses
+// plus a margin.
+func (cd *Table) xlsxWidths() []int {
+	const margin = 2
+
+	cells := [][]string{cd.ColNames}
+	for row := 0; row < cd.nRows(); row++ {
+		line := []string{cd.RowNames[row]}
+		for col := 0; col < len(cd.Data); col++ {
+			line = append(line, strings.Trim(cd.cell(col, row, false), " "))
+		}
+
+		cells = append(cells, line)
+	}
+
+	widths := colWidths(cells)
+	for col := range widths {
+		widths[col] += margin
+	}
+
+	return widths
+}
+
+// xlsxRef returns the Excel reference (e.g. "B3") of the 0-based column col and 1-based row
+func xlsxRef(col, row int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+
+	return fmt.Sprintf("%s%d", name, row)
+}
+
+// xlsxCell returns the XML for a cell at ref with value x. Missing values (see IsNA) produce no cell.
+// Pointers are dereferenced.
+func xlsxCell(ref string, x any) string {
+	x = naValue(x)
+	switch val := x.(type) {
+	case nil:
+		return ""
+	case string:
+		if val == "" {
+			return ""
+		}
+
+		return xlsxString(ref, val, xlsxGeneral)
+	case bool:
+		b := 0
+		if val {
+			b = 1
+		}
+
+		return fmt.Sprintf(`<c r="%s" t="b"><v>%d</v></c>`, ref, b)
+	case time.Time:
+		if val.IsZero() {
+			return ""
+		}
+
+		return fmt.Sprintf(`<c r="%s" s="%d"><v>%s</v></c>`, ref, xlsxDate, RawString(excelSerial(val)))
+	case float32, float64:
+		xf, _ := numberOf(val)
+		if math.IsNaN(xf) || math.IsInf(xf, 0) {
+			return ""
+		}
+
+		return fmt.Sprintf(`<c r="%s"><v>%s</v></c>`, ref, RawString(val))
+	}
+
+	if _, ok := numberOf(x); ok {
+		return fmt.Sprintf(`<c r="%s" s="%d"><v>%s</v></c>`, ref, xlsxInt, RawString(x))
+	}
+
+	return xlsxString(ref, fmt.Sprintf("%v", x), xlsxGeneral)
+}
+
+// xlsxString returns the XML for an inline string cell at ref
+func xlsxString(ref, str string, style int) string {
+	return fmt.Sprintf(`<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, xmlEscape(str))
+}
+
+// excelSerial returns dt as an Excel serial date: the number of days since 1899-12-30
+func excelSerial(dt time.Time) float64 {
+	wall := time.Date(dt.Year(), dt.Month(), dt.Day(), dt.Hour(), dt.Minute(), dt.Second(), dt.Nanosecond(), time.UTC)
+
+	return wall.Sub(time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)).Hours() / 24
+}
+
+// xmlEscape escapes str for use as XML text or an attribute value
+func xmlEscape(str string) string {
+	var sb strings.Builder
+	_ = xml.EscapeText(&sb, []byte(str))
+
+	return sb.String()
+}
@@ -3,7 +3,9 @@ package utilities
 import (
 	"bufio"
 	"bytes"
+	"cmp"
 	"crypto/rand"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"github.com/ClickHouse/clickhouse-go/v2"
@@ -13,12 +15,16 @@ import (
 	"io/fs"
 	"math"
 	"math/big"
+	mrand "math/rand/v2"
 	"os"
 	"reflect"
+	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/invertedv/chutils"
 	f "github.com/invertedv/chutils/file"
@@ -54,19 +60,272 @@ func Has(needle, delim string, haystack ...string) bool {
 
 // DeDupeSlice removes duplicate entries of inSlc, sorting if sortReturn
 func DeDupeSlice(inSlc []string, sortReturn bool) (outSlc []string) {
-	for _, val := range inSlc {
-		if !Has(val, ",", outSlc...) {
-			outSlc = append(outSlc, val)
+	if sortReturn {
+		return UniqueSorted(inSlc)
+	}
+
+	return Unique(inSlc)
+}
+
+// Index returns the index of the first occurrence of needle in haystack, -1 if it is not there.
+func Index[T comparable](needle T, haystack ...T) int {
+	for ind, straw := range haystack {
+		if straw == needle {
+			return ind
 		}
 	}
 
-	if sortReturn {
-		sort.Strings(outSlc)
+	return -1
+}
+
+// Contains returns true if needle is in haystack
+func Contains[T comparable](needle T, haystack ...T) bool {
+	return Index(needle, haystack...) >= 0
+}
+
+// Unique returns the distinct elements of inSlc in the order of their first occurrence.
+// Note that time.Time values are equal only if their locations are, too.
+func Unique[T comparable](inSlc []T) []T {
+	var outSlc []T
+
+	seen := make(map[T]bool, len(inSlc))
+	for _, x := range inSlc {
+		if !seen[x] {
+			seen[x] = true
+			outSlc = append(outSlc, x)
+		}
+	}
+
+	return outSlc
+}
+
+// UniqueSorted returns the distinct elements of inSlc in ascending order
+func UniqueSorted[T cmp.Ordered](inSlc []T) []T {
+	outSlc := Unique(inSlc)
+	slices.Sort(outSlc)
+
+	return outSlc
+}
+
+// Union returns the distinct elements that are in a or b, in the order of their first occurrence in a then b
+func Union[T comparable](a, b []T) []T {
+	return Unique(append(append([]T{}, a...), b...))
+}
+
+// Intersection returns the distinct elements of a that are also in b, in the order of their first occurrence in a
+func Intersection[T comparable](a, b []T) []T {
+	inB := toSet(b)
+
+	var outSlc []T
+	for _, x := range Unique(a) {
+		if inB[x] {
+			outSlc = append(outSlc, x)
+		}
+	}
+
+	return outSlc
+}
+
+// Difference returns the distinct elements of a that are not in b, in the order of their first occurrence in a
+func Difference[T comparable](a, b []T) []T {
+	inB := toSet(b)
+
+	var outSlc []T
+	for _, x := range Unique(a) {
+		if !inB[x] {
+			outSlc = append(outSlc, x)
+		}
+	}
+
+	return outSlc
+}
+
+// toSet returns a map whose keys are the elements of inSlc
+func toSet[T comparable](inSlc []T) map[T]bool {
+	set := make(map[T]bool, len(inSlc))
+	for _, x := range inSlc {
+		set[x] = true
+	}
+
+	return set
+}
+
+// Levenshtein returns the edit distance between a and b: the number of rune insertions, deletions and
+// substitutions needed to change a into b.
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	prev, curr := make([]int, len(rb)+1), make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = MinInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// DamerauLevenshtein returns the edit distance between a and b, counting the transposition of adjacent runes
+// as one edit. This is the optimal string alignment distance: no substring is edited more than once.
+func DamerauLevenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			d[i][j] = MinInt(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = MinInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(ra)][len(rb)]
+}
+
+// JaroWinkler returns the Jaro-Winkler similarity of a and b. It is 1 if they are identical and 0 if they have
+// nothing in common. Common prefixes, up to 4 runes, increase the similarity.
+func JaroWinkler(a, b string) float64 {
+	const (
+		maxPrefix = 4
+		scale     = 0.1
+	)
+
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+
+	// runes match if they are equal and no further apart than window
+	window := MaxInt(0, MaxInt(len(ra), len(rb))/2-1)
+	matchA, matchB := make([]bool, len(ra)), make([]bool, len(rb))
+
+	matches := 0
+	for i := range ra {
+		for j := MaxInt(0, i-window); j < MinInt(len(rb), i+window+1); j++ {
+			if !matchB[j] && ra[i] == rb[j] {
+				matchA[i], matchB[j] = true, true
+				matches++
+
+				break
+			}
+		}
+	}
+
+	if matches == 0 {
+		return 0
+	}
+
+	// transpositions are half the matched runes that are out of order
+	transpositions, j := 0, 0
+	for i := range ra {
+		if !matchA[i] {
+			continue
+		}
+
+		for !matchB[j] {
+			j++
+		}
+
+		if ra[i] != rb[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(ra)) + m/float64(len(rb)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < MinInt(maxPrefix, len(ra), len(rb)) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+
+	return jaro + float64(prefix)*scale*(1-jaro)
+}
+
+// ClosestMatch returns the n elements of haystack closest to needle, closest first. Case is ignored.
+// Elements are ranked by DamerauLevenshtein distance, with ties broken by JaroWinkler similarity.
+// If n is not positive, all of haystack is returned.
+func ClosestMatch(needle string, haystack []string, n int) []string {
+	type scored struct {
+		straw string
+		dist  int
+		sim   float64
+	}
+
+	needle = strings.ToLower(needle)
+
+	scores := make([]scored, len(haystack))
+	for ind, straw := range haystack {
+		lower := strings.ToLower(straw)
+		scores[ind] = scored{straw: straw, dist: DamerauLevenshtein(needle, lower), sim: JaroWinkler(needle, lower)}
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool {
+		if scores[i].dist != scores[j].dist {
+			return scores[i].dist < scores[j].dist
+		}
+
+		return scores[i].sim > scores[j].sim
+	})
+
+	if n <= 0 || n > len(scores) {
+		n = len(scores)
+	}
+
+	outSlc := make([]string, n)
+	for ind := 0; ind < n; ind++ {
+		outSlc[ind] = scores[ind].straw
 	}
 
 	return outSlc
 }
 
+// DidYouMean returns ", did you mean 'x'?" where x is the element of haystack closest to needle, or "" if none
+// is close. It is meant to be appended to error messages.
+func DidYouMean(needle string, haystack []string) string {
+	const minSimilarity = 0.85
+
+	best := ClosestMatch(needle, haystack, 1)
+	if len(best) == 0 {
+		return ""
+	}
+
+	a, b := strings.ToLower(needle), strings.ToLower(best[0])
+	if DamerauLevenshtein(a, b) > MaxInt(1, len([]rune(a))/3) && JaroWinkler(a, b) < minSimilarity {
+		return ""
+	}
+
+	return fmt.Sprintf(", did you mean '%s'?", best[0])
+}
+
 // Matched returns a substring that is between the outermost set of startChar/endChar
 func Matched(inStr, startChar, endChar string) (string, error) {
 	start, ignore := -1, 0
@@ -93,6 +352,113 @@ func Matched(inStr, startChar, endChar string) (string, error) {
 	return "", fmt.Errorf("unmatched startChar")
 }
 
+// Match is a substring found by MatchedAll
+type Match struct {
+	Text  string // Text is the substring between the open and close tokens
+	Start int    // Start is the byte offset of the open token
+	End   int    // End is the byte offset just past the close token
+	Depth int    // Depth is the nesting depth of the match, 0 is outermost
+}
+
+// MatchedAll returns every substring of inStr between matching open and close tokens, which may be more than
+// one character, ordered by Start. Nested matches are included.
+// Text within any of the quote delimiters is skipped, as is the character after a backslash.
+// If open and close are the same, they alternate.
+func MatchedAll(inStr, open, closer string, quotes ...string) ([]Match, error) {
+	matches, e := matchAll(inStr, open, closer, quotes, false)
+	if e != nil {
+		return nil, fmt.Errorf("%v: MatchedAll", e)
+	}
+
+	return matches, nil
+}
+
+// matchAll implements MatchedAll. If firstOnly, it returns just the first outermost match and ignores the rest of inStr.
+func matchAll(inStr, open, closer string, quotes []string, firstOnly bool) ([]Match, error) {
+	if open == "" || closer == "" {
+		return nil, fmt.Errorf("empty open or close token")
+	}
+
+	var (
+		matches []Match
+		starts  []int // offsets of the open tokens not yet closed
+		quote   string
+		qStart  int
+	)
+
+	for ind := 0; ind < len(inStr); {
+		rest := inStr[ind:]
+
+		if rest[0] == '\\' {
+			ind += 2
+			continue
+		}
+
+		if quote != "" {
+			ind++
+			if strings.HasPrefix(rest, quote) {
+				ind += len(quote) - 1
+				quote = ""
+			}
+
+			continue
+		}
+
+		if q := quoteAt(rest, quotes); q != "" {
+			quote, qStart = q, ind
+			ind += len(q)
+			continue
+		}
+
+		isClose := strings.HasPrefix(rest, closer) && (open != closer || len(starts) > 0)
+
+		switch {
+		case isClose:
+			if len(starts) == 0 {
+				return nil, fmt.Errorf("unmatched %s at %d", closer, ind)
+			}
+
+			start := starts[len(starts)-1]
+			starts = starts[:len(starts)-1]
+			matches = append(matches,
+				Match{Text: inStr[start+len(open) : ind], Start: start, End: ind + len(closer), Depth: len(starts)})
+			ind += len(closer)
+
+			if firstOnly && len(starts) == 0 {
+				return matches[len(matches)-1:], nil
+			}
+		case strings.HasPrefix(rest, open):
+			starts = append(starts, ind)
+			ind += len(open)
+		default:
+			ind++
+		}
+	}
+
+	if quote != "" {
+		return nil, fmt.Errorf("unterminated quote %s at %d", quote, qStart)
+	}
+
+	if len(starts) > 0 {
+		return nil, fmt.Errorf("unmatched %s at %d", open, starts[len(starts)-1])
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Start < matches[j].Start })
+
+	return matches, nil
+}
+
+// quoteAt returns the element of quotes that inStr starts with, "" if there is none
+func quoteAt(inStr string, quotes []string) string {
+	for _, q := range quotes {
+		if q != "" && strings.HasPrefix(inStr, q) {
+			return q
+		}
+	}
+
+	return ""
+}
+
 // YesNo determines if inStr is yes/no, return true if "yes"
 func YesNo(inStr string) (bool, error) {
 	if inStr != "yes" && inStr != "no" && inStr != "" {
@@ -106,6 +472,75 @@ func YesNo(inStr string) (bool, error) {
 	return true, nil
 }
 
+// BoolWords are the words ParseBool accepts by default and their values
+var BoolWords = map[string]bool{
+	"true": true, "t": true, "yes": true, "y": true, "1": true, "on": true,
+	"false": false, "f": false, "no": false, "n": false, "0": false, "off": false,
+}
+
+// ParseBool returns the value of inStr in the vocabulary words, ignoring case and surrounding spaces.
+// If words is nil, BoolWords is used. The keys of words must be lower case.
+func ParseBool(inStr string, words map[string]bool) (bool, error) {
+	if words == nil {
+		words = BoolWords
+	}
+
+	if val, ok := words[strings.ToLower(strings.TrimSpace(inStr))]; ok {
+		return val, nil
+	}
+
+	var choices []string
+	for word := range words {
+		choices = append(choices, word)
+	}
+
+	sort.Strings(choices)
+
+	return false, fmt.Errorf("expected one of %s, got %q: ParseBool", strings.Join(choices, ", "), inStr)
+}
+
+// ParseEnum returns the element of choices whose String() is inStr, ignoring case and surrounding spaces.
+// inStr may also be a prefix that matches only one of choices. For example,
+//
+//	ParseEnum("pn", PlotlyPNG, PlotlyPDF, PlotlyJPEG)
+//
+// returns PlotlyPNG.
+func ParseEnum[T fmt.Stringer](inStr string, choices ...T) (T, error) {
+	var (
+		zero    T
+		matches []T
+		names   []string
+	)
+
+	needle := strings.ToLower(strings.TrimSpace(inStr))
+	for _, choice := range choices {
+		name := strings.ToLower(choice.String())
+		names = append(names, choice.String())
+
+		if name == needle {
+			return choice, nil
+		}
+
+		if needle != "" && strings.HasPrefix(name, needle) {
+			matches = append(matches, choice)
+		}
+	}
+
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		return zero, fmt.Errorf("expected one of %s, got %q: ParseEnum", strings.Join(names, ", "), inStr)
+	}
+
+	var ambiguous []string
+	for _, m := range matches {
+		ambiguous = append(ambiguous, m.String())
+	}
+
+	return zero, fmt.Errorf("%q is ambiguous, it could be any of %s: ParseEnum", inStr, strings.Join(ambiguous, ", "))
+}
+
 // ***************  Math
 
 // MaxInt returns the maximum of ints
@@ -197,6 +632,100 @@ func RandNorm(n int) ([]float64, error) {
 	return xNorm, nil
 }
 
+// Rand is a source of random numbers with the same methods as the package-level Rand* functions.
+// The package-level functions draw from crypto/rand. A Rand created by NewRand is deterministic, so
+// results can be reproduced. A Rand is not safe for concurrent use.
+type Rand struct {
+	rng *mrand.Rand
+}
+
+// NewRand returns a Rand using the PCG generator seeded with seed. Rands with the same seed produce the
+// same sequence.
+func NewRand(seed uint64) *Rand {
+	const stream = 0x9e3779b97f4a7c15 // any fixed value will do
+
+	return &Rand{rng: mrand.New(mrand.NewPCG(seed, stream))}
+}
+
+// NewCryptoRand returns a Rand drawing from crypto/rand, as the package-level functions do.
+// Its methods panic if crypto/rand fails.
+func NewCryptoRand() *Rand {
+	return &Rand{rng: mrand.New(cryptoSource{})}
+}
+
+// cryptoSource is a math/rand/v2 Source that draws from crypto/rand
+type cryptoSource struct{}
+
+func (cryptoSource) Uint64() uint64 {
+	var b [8]byte
+	if _, e := rand.Read(b[:]); e != nil {
+		panic(e)
+	}
+
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+// UnifInt generates a slice whose elements are random U[0,upper) int64's
+func (r *Rand) UnifInt(n, upper int) ([]int64, error) {
+	if upper <= 0 {
+		return nil, fmt.Errorf("upper must be positive, got %d: UnifInt", upper)
+	}
+
+	if n < 0 {
+		return nil, fmt.Errorf("n must be non-negative, got %d: UnifInt", n)
+	}
+
+	outInts := make([]int64, n)
+	for ind := 0; ind < n; ind++ {
+		outInts[ind] = r.rng.Int64N(int64(upper))
+	}
+
+	return outInts, nil
+}
+
+// UnifFlt generates a slice whose elements are random U(0, 1) floats
+func (r *Rand) UnifFlt(n int) ([]float64, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("n must be non-negative, got %d: UnifFlt", n)
+	}
+
+	us := make([]float64, n)
+	for ind := 0; ind < n; ind++ {
+		// Float64 is on [0,1)
+		for us[ind] == 0 {
+			us[ind] = r.rng.Float64()
+		}
+	}
+
+	return us, nil
+}
+
+// Norm generates a slice whose elements are N(0,1)
+func (r *Rand) Norm(n int) ([]float64, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("n must be non-negative, got %d: Norm", n)
+	}
+
+	xNorm := make([]float64, n)
+	for ind := 0; ind < n; ind++ {
+		xNorm[ind] = r.rng.NormFloat64()
+	}
+
+	return xNorm, nil
+}
+
+// Letters generates a string of length "length" by randomly choosing from a-z
+func (r *Rand) Letters(length int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyz"
+
+	var sb strings.Builder
+	for ind := 0; ind < length; ind++ {
+		sb.WriteByte(letters[r.rng.IntN(len(letters))])
+	}
+
+	return sb.String()
+}
+
 // ***************  Files
 
 // TempFile produces a random temp file name in the system's tmp location.
@@ -448,7 +977,8 @@ func GetTTYnoecho(prompt string) string {
 
 // ***************  Misc
 
-// ReplaceSmart replaces old with new except when it occurs within delim
+// ReplaceSmart replaces old with new except when it occurs within delim.
+// oldChar, newChar and delim must be single characters. See ReplaceQuoted for the general case.
 func ReplaceSmart(source, oldChar, newChar, delim string) string {
 	if len(oldChar) > 1 || len(newChar) > 1 || len(delim) > 1 {
 		panic(fmt.Errorf("old, new or delim has multiple characters in ReplaceSmart"))
@@ -471,6 +1001,72 @@ func ReplaceSmart(source, oldChar, newChar, delim string) string {
 	return replaced
 }
 
+// ReplaceQuoted replaces every occurrence of oldStr in source with newStr, except within any of the quote delimiters
+// or after a backslash. A doubled quote within a quoted region is an escaped quote, so the region continues.
+// Outside quotes, an oldStr that starts with a backslash is replaced rather than read as an escape.
+// An error is returned if oldStr is empty or a quote is not terminated.
+func ReplaceQuoted(source, oldStr, newStr string, quotes ...string) (string, error) {
+	if oldStr == "" {
+		return "", fmt.Errorf("oldStr is empty: ReplaceQuoted")
+	}
+
+	var (
+		sb     strings.Builder
+		quote  string
+		qStart int
+	)
+
+	sb.Grow(len(source))
+
+	for ind := 0; ind < len(source); {
+		rest := source[ind:]
+
+		if rest[0] == '\\' && (quote != "" || !strings.HasPrefix(rest, oldStr)) {
+			n := MinInt(2, len(rest))
+			sb.WriteString(rest[:n])
+			ind += n
+
+			continue
+		}
+
+		if quote != "" {
+			n := 1
+			if strings.HasPrefix(rest, quote) {
+				n, quote = len(quote), ""
+			}
+
+			sb.WriteString(rest[:n])
+			ind += n
+
+			continue
+		}
+
+		if q := quoteAt(rest, quotes); q != "" {
+			quote, qStart = q, ind
+			sb.WriteString(q)
+			ind += len(q)
+
+			continue
+		}
+
+		if strings.HasPrefix(rest, oldStr) {
+			sb.WriteString(newStr)
+			ind += len(oldStr)
+
+			continue
+		}
+
+		sb.WriteByte(rest[0])
+		ind++
+	}
+
+	if quote != "" {
+		return "", fmt.Errorf("unterminated quote %s at %d: ReplaceQuoted", quote, qStart)
+	}
+
+	return sb.String(), nil
+}
+
 // ToLastDay moves a date to the last day of the month
 func ToLastDay(dt time.Time) (eom time.Time) {
 	yr, mon := dt.Year(), dt.Month()
@@ -538,12 +1134,12 @@ func Aligner[ChLeft, ChRight any | int32 | int64 | float32 | float64 | string |
 	var leftStr, outStr []string
 	for ind := 0; ind < len(left); ind++ {
 		str := fmt.Sprintf("%v", left[ind])
-		maxLen = MaxInt(maxLen, len(str))
+		maxLen = MaxInt(maxLen, DisplayWidth(str))
 		leftStr = append(leftStr, str)
 	}
 
 	for ind := 0; ind < len(left); ind++ {
-		padding := strings.Repeat(" ", maxLen-len(leftStr[ind])+pad)
+		padding := strings.Repeat(" ", maxLen-DisplayWidth(leftStr[ind])+pad)
 		str := fmt.Sprintf("%s%s%v", leftStr[ind], padding, right[ind])
 		outStr = append(outStr, str)
 	}
@@ -551,6 +1147,57 @@ func Aligner[ChLeft, ChRight any | int32 | int64 | float32 | float64 | string |
 	return outStr
 }
 
+// ansiEscape matches ANSI terminal escape sequences (colors, bold, etc.)
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+// wideRunes are the ranges of East Asian wide and fullwidth characters and emoji, which occupy two terminal columns
+var wideRunes = [][2]rune{
+	{0x1100, 0x115F}, {0x231A, 0x231B}, {0x2329, 0x232A}, {0x23E9, 0x23EC}, {0x23F0, 0x23F0}, {0x23F3, 0x23F3},
+	{0x25FD, 0x25FE}, {0x2614, 0x2615}, {0x2648, 0x2653}, {0x267F, 0x267F}, {0x2693, 0x2693}, {0x26A1, 0x26A1},
+	{0x26AA, 0x26AB}, {0x26BD, 0x26BE}, {0x26C4, 0x26C5}, {0x26CE, 0x26CE}, {0x26D4, 0x26D4}, {0x26EA, 0x26EA},
+	{0x26F2, 0x26F3}, {0x26F5, 0x26F5}, {0x26FA, 0x26FA}, {0x26FD, 0x26FD}, {0x2705, 0x2705}, {0x270A, 0x270B},
+	{0x2728, 0x2728}, {0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755}, {0x2757, 0x2757}, {0x2795, 0x2797},
+	{0x27B0, 0x27B0}, {0x27BF, 0x27BF}, {0x2B1B, 0x2B1C}, {0x2B50, 0x2B50}, {0x2B55, 0x2B55}, {0x2E80, 0x303E},
+	{0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF}, {0xA000, 0xA4CF}, {0xA960, 0xA97F}, {0xAC00, 0xD7A3},
+	{0xF900, 0xFAFF}, {0xFE10, 0xFE19}, {0xFE30, 0xFE6F}, {0xFF00, 0xFF60}, {0xFFE0, 0xFFE6}, {0x1F004, 0x1F004},
+	{0x1F0CF, 0x1F0CF}, {0x1F18E, 0x1F18E}, {0x1F191, 0x1F19A}, {0x1F200, 0x1F202}, {0x1F210, 0x1F23B},
+	{0x1F240, 0x1F248}, {0x1F250, 0x1F251}, {0x1F260, 0x1F265}, {0x1F300, 0x1F64F}, {0x1F680, 0x1F6FF},
+	{0x1F7E0, 0x1F7EB}, {0x1F900, 0x1F9FF}, {0x1FA70, 0x1FAFF}, {0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
+
+// RuneWidth returns the number of terminal columns r occupies: 0 for combining marks and control characters,
+// 2 for East Asian wide characters and emoji, 1 otherwise.
+func RuneWidth(r rune) int {
+	if r < 0x20 || (r >= 0x7F && r < 0xA0) || unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+
+	if r < wideRunes[0][0] {
+		return 1
+	}
+
+	ind := sort.Search(len(wideRunes), func(i int) bool { return wideRunes[i][1] >= r })
+	if ind < len(wideRunes) && r >= wideRunes[ind][0] {
+		return 2
+	}
+
+	return 1
+}
+
+// DisplayWidth returns the number of terminal columns inStr occupies. ANSI escape sequences are ignored.
+func DisplayWidth(inStr string) int {
+	if strings.Contains(inStr, "\x1b") {
+		inStr = ansiEscape.ReplaceAllString(inStr, "")
+	}
+
+	width := 0
+	for _, r := range inStr {
+		width += RuneWidth(r)
+	}
+
+	return width
+}
+
 // ***************  Type Conversions
 
 // GTAny compares xa > xb
@@ -676,7 +1323,8 @@ func Comparer(xa, xb any, comp string) (truth bool, err error) {
 func Any2Date(inVal any) (*time.Time, error) {
 	switch x := inVal.(type) {
 	case string:
-		formats := []string{"20060102", "1/2/2006", "01/02/2006", "Jan 2, 2006", "January 2, 2006", "Jan 2 2006", "January 2 2006"}
+		formats := []string{"20060102", "2006-01-02", "1/2/2006", "01/02/2006", "Jan 2, 2006", "January 2, 2006", "Jan 2 2006", "January 2 2006",
+			time.RFC3339Nano}
 		for _, fmtx := range formats {
 			dt, e := time.Parse(fmtx, strings.ReplaceAll(x, "'", ""))
 			if e == nil {
@@ -949,17 +1597,14 @@ func String2Kind(str string) reflect.Kind {
 	}
 }
 
-// PrettyString returns a string version of x suitable for printing
+// PrettyString returns a string version of x suitable for printing. Missing values (see IsNA) are NAToken.
 func PrettyString(x any) string {
-	// for determining # of places after the decimal
-	const (
-		t1 = 0.1
-		t2 = 1.0
-		t3 = 10.0
-	)
+	if IsNA(x) {
+		return NAToken
+	}
 
-	if x == nil {
-		return ""
+	if val := reflect.ValueOf(x); val.Kind() == reflect.Pointer {
+		return PrettyString(val.Elem().Interface())
 	}
 
 	switch val := x.(type) {
@@ -970,25 +1615,51 @@ func PrettyString(x any) string {
 	case int64:
 		return humanize.Comma(val)
 	case float64:
-		r := math.Abs(val)
-		switch {
-		case r < t1:
-			return fmt.Sprintf("%0.4f", val)
-		case r < t2:
-			return fmt.Sprintf("%0.3f", val)
-		case r < t3:
-			return fmt.Sprintf("%0.2f", val)
-		default:
-			return fmt.Sprintf("%0.1f", val)
-		}
+		return strconv.FormatFloat(val, 'f', prettyPlaces(val), 64)
 	case float32:
 		return PrettyString(float64(val))
 	case string:
 		return val
 	case time.Time:
 		return val.Format("2006-01-02")
+	case bool:
+		return strconv.FormatBool(val)
+	}
+
+	if val := reflect.ValueOf(x); val.CanInt() {
+		return humanize.Comma(val.Int())
+	}
+
+	if val := reflect.ValueOf(x); val.CanUint() {
+		if val.Uint() > math.MaxInt64 {
+			return strconv.FormatUint(val.Uint(), 10)
+		}
+
+		return humanize.Comma(int64(val.Uint()))
+	}
+
+	return ""
+}
+
+// prettyPlaces returns the number of places after the decimal PrettyString uses for x
+func prettyPlaces(x float64) int {
+	// for determining # of places after the decimal
+	const (
+		t1 = 0.1
+		t2 = 1.0
+		t3 = 10.0
+	)
+
+	r := math.Abs(x)
+	switch {
+	case r < t1:
+		return 4
+	case r < t2:
+		return 3
+	case r < t3:
+		return 2
 	default:
-		return ""
+		return 1
 	}
 }
 
@@ -1,8 +1,14 @@
 package utilities
 
 import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
 	"math"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -27,6 +33,20 @@ func TestTableToCSV(t *testing.T) {
 	assert.Nil(t, e)
 }
 
+func TestNewTableFromQuery(t *testing.T) {
+	user := os.Getenv("user")
+	pw := os.Getenv("pw")
+	host := os.Getenv("host")
+	conn, e1 := MakeConnection(host, user, pw, 100000, 10000, 1)
+	assert.Nil(t, e1)
+	defer func() { _ = conn.Close() }()
+
+	tbl, e := NewTableFromQuery("SELECT month, expCPR FROM tmp.poolx ORDER BY month", "month", 5, conn)
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"month", "expCPR"}, tbl.ColNames)
+	assert.Equal(t, 5, len(tbl.RowNames))
+}
+
 func TestReplaceSmart(t *testing.T) {
 	inp := " ' x' "
 	exp := "' x'"
@@ -346,3 +366,996 @@ func TestAligner(t *testing.T) {
 	exp = []string{"1      a", "10     b", "100    c", "1000   d"}
 	assert.Equal(t, exp, act)
 }
+
+func testTable() *Table {
+	return &Table{
+		RowNames: []string{"a", "b"},
+		ColNames: []string{"name", "count", "rate", "label"},
+		Data: [][]any{
+			{int64(1200), int64(3)},
+			{0.25, 12.5},
+			{"x|y", "z"},
+		},
+	}
+}
+
+func TestTableRender(t *testing.T) {
+	tbl := testTable()
+
+	act, e := tbl.Render(TableCSV, false)
+	assert.Nil(t, e)
+	assert.Equal(t, "name,count,rate,label\na,\"1,200\",0.250,x|y\nb,3,12.5,z\n", act)
+
+	act, e = tbl.Render(TableTSV, true)
+	assert.Nil(t, e)
+	assert.Equal(t, "name\tcount\trate\tlabel\na\t1200\t0.25\tx|y\nb\t3\t12.5\tz\n", act)
+
+	act, e = tbl.Render(TableJSONRecords, true)
+	assert.Nil(t, e)
+	assert.Equal(t, "[\n  {\"name\": \"a\", \"count\": 1200, \"rate\": 0.25, \"label\": \"x|y\"},\n"+
+		"  {\"name\": \"b\", \"count\": 3, \"rate\": 12.5, \"label\": \"z\"}\n]\n", act)
+
+	act, e = tbl.Render(TableJSONColumns, true)
+	assert.Nil(t, e)
+	assert.Equal(t, "{\n  \"name\": [\"a\",\"b\"],\n  \"count\": [1200,3],\n  \"rate\": [0.25,12.5],\n  \"label\": [\"x|y\",\"z\"]\n}\n", act)
+
+	act, e = tbl.Render(TableHTML, false)
+	assert.Nil(t, e)
+	assert.Contains(t, act, "<tr><th>a</th><td>1,200</td><td>0.250</td><td>x|y</td></tr>")
+
+	act, e = tbl.Render(TableLaTeX, false)
+	assert.Nil(t, e)
+	assert.Contains(t, act, "\\begin{tabular}{lrrl}")
+	assert.Contains(t, act, "a & 1,200 & 0.250 & x|y \\\\")
+
+	tbl.ColNames = tbl.ColNames[1:]
+	_, e = tbl.Render(TableCSV, false)
+	assert.NotNil(t, e)
+
+	// row names survive when there are no Data columns, as DropEmptyCols can leave
+	names := &Table{RowNames: []string{"a", "b"}, ColNames: []string{"name"}}
+	act, e = names.Render(TableCSV, false)
+	assert.Nil(t, e)
+	assert.Equal(t, "name\na\nb\n", act)
+
+	act, e = names.Render(TableJSONRecords, true)
+	assert.Nil(t, e)
+	assert.Equal(t, "[\n  {\"name\": \"a\"},\n  {\"name\": \"b\"}\n]\n", act)
+
+	dt := time.Date(2024, 5, 6, 13, 14, 15, 0, time.UTC)
+	assert.Equal(t, "2024-05-06T13:14:15Z", RawString(dt))
+	assert.Equal(t, "2024-05-06", RawString(time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)))
+
+	back, e := Any2Date(RawString(dt))
+	assert.Nil(t, e)
+	assert.True(t, dt.Equal(*back))
+}
+
+func TestTableMarkdown(t *testing.T) {
+	tbl := testTable()
+
+	act, e := tbl.Render(TableMarkdown, false)
+	assert.Nil(t, e)
+	exp := "| name | count |  rate | label |\n" +
+		"| :--- | ----: | ----: | :---- |\n" +
+		"| a    | 1,200 | 0.250 | x\\|y  |\n" +
+		"| b    |     3 |  12.5 | z     |\n"
+	assert.Equal(t, exp, act)
+}
+
+func TestParseTable(t *testing.T) {
+	tbl := testTable()
+	tbl.ColNames = append(tbl.ColNames, "date")
+	tbl.Data = append(tbl.Data, []any{time.Date(2023, 4, 30, 0, 0, 0, 0, time.UTC), nil})
+
+	for _, format := range []TableFormat{TablePlain, TableMarkdown, TableCSV, TableTSV} {
+		str, e := tbl.Render(format, false)
+		assert.Nil(t, e)
+
+		act, e := ParseTable(strings.NewReader(str), format)
+		assert.Nil(t, e)
+		assert.Equal(t, tbl.RowNames, act.RowNames)
+		assert.Equal(t, tbl.ColNames, act.ColNames)
+		assert.Equal(t, tbl.Data, act.Data)
+	}
+
+	_, e := ParseTable(strings.NewReader("a,b\n1,2,3\n"), TableCSV)
+	assert.NotNil(t, e)
+
+	// rows of dashes or blanks are data, only the line after the header is the separator
+	md := "| a | b |\n|---|--:|\n| - | - |\n|  |  |\n| x | 1 |\n"
+	act, e := ParseTable(strings.NewReader(md), TableMarkdown)
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"-", "", "x"}, act.RowNames)
+	assert.Equal(t, []any{"-", nil, "1"}, act.Data[0])
+
+	_, e = ParseTable(strings.NewReader("| a | b |\n| x | 1 |\n"), TableMarkdown)
+	assert.NotNil(t, e)
+
+	// an all-missing row is a blank line in a plain table
+	tbl = &Table{RowNames: []string{"a", "", "c"}, ColNames: []string{"name", "x", "y"},
+		Data: [][]any{{int64(1), nil, int64(3)}, {"p", nil, "r"}}}
+	act, e = ParseTable(strings.NewReader(tbl.String()), TablePlain)
+	assert.Nil(t, e)
+	assert.Equal(t, tbl.RowNames, act.RowNames)
+	assert.Equal(t, tbl.Data, act.Data)
+
+	// gutters are found by display width, so wide and accented characters do not shift the columns
+	tbl = &Table{
+		RowNames: []string{"日本語", "b", "café"},
+		ColNames: []string{"name", "v", "w"},
+		Data:     [][]any{{int64(1), int64(22), int64(3)}, {"naïve", "東京 x", "été"}},
+	}
+
+	act, e = ParseTable(strings.NewReader(tbl.String()), TablePlain)
+	assert.Nil(t, e)
+	assert.Equal(t, tbl.ColNames, act.ColNames)
+	assert.Equal(t, tbl.RowNames, act.RowNames)
+	assert.Equal(t, tbl.Data, act.Data)
+}
+
+func TestTableFromStructs(t *testing.T) {
+	type rec struct {
+		Name  string    `table:"name"`
+		Count int64     `table:"count"`
+		Rate  float64   `table:"rate,%.1f%%"`
+		Date  time.Time `table:"date"`
+		Skip  string    `table:"-"`
+		notIn int
+	}
+
+	dt := time.Date(2023, 4, 30, 0, 0, 0, 0, time.UTC)
+	recs := []rec{{"a", 1200, 12.5, dt, "x", 1}, {"b", 3, 0.25, dt, "y", 2}}
+
+	tbl, e := TableFromStructs(recs, "name")
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"a", "b"}, tbl.RowNames)
+	assert.Equal(t, []string{"name", "count", "rate", "date"}, tbl.ColNames)
+	assert.Equal(t, []any{int64(1200), int64(3)}, tbl.Data[0])
+	assert.Equal(t, "12.5%", tbl.cell(1, 0, false))
+
+	var back []*rec
+	assert.Nil(t, tbl.ToStructs(&back))
+	assert.Equal(t, 2, len(back))
+	assert.Equal(t, rec{Name: "b", Count: 3, Rate: 0.25, Date: dt}, *back[1])
+
+	// conversion from strings via Any2Kind
+	tbl.Data[0] = []any{"7", "8"}
+	var conv []rec
+	assert.Nil(t, tbl.ToStructs(&conv))
+	assert.Equal(t, int64(8), conv[1].Count)
+
+	// kinds Any2Kind does not cover, as read from a file or ClickHouse
+	type kinds struct {
+		Small  int8
+		Medium int16
+		Count  uint32
+		Flag   bool
+		Ptr    *uint64
+	}
+
+	kt := &Table{RowNames: []string{"1", "2"}, ColNames: []string{"", "Small", "Medium", "Count", "Flag", "Ptr"},
+		Data: [][]any{
+			{int64(-3), "4"},
+			{int64(300), 5.0},
+			{int64(7), "8"},
+			{"true", "no"},
+			{int64(9), nil},
+		},
+	}
+
+	var ks []kinds
+	assert.Nil(t, kt.ToStructs(&ks))
+	nine := uint64(9)
+	assert.Equal(t, kinds{Small: -3, Medium: 300, Count: 7, Flag: true, Ptr: &nine}, ks[0])
+	assert.Equal(t, kinds{Small: 4, Medium: 5, Count: 8}, ks[1])
+
+	kt.Data[0][0] = int64(200)
+	assert.NotNil(t, kt.ToStructs(&ks))
+	kt.Data[0][0] = int8(1)
+	kt.Data[2][0] = int64(-1)
+	assert.NotNil(t, kt.ToStructs(&ks))
+	kt.Data[2][0] = uint32(1)
+	kt.Data[3][0] = "maybe"
+	assert.NotNil(t, kt.ToStructs(&ks))
+
+	// fractions are not truncated into int fields
+	type whole struct{ N int }
+	wt := &Table{RowNames: []string{"1"}, ColNames: []string{"", "N"}, Data: [][]any{{2.0}}}
+	var ws []whole
+	assert.Nil(t, wt.ToStructs(&ws))
+	assert.Equal(t, 2, ws[0].N)
+	wt.Data[0][0] = 2.7
+	assert.NotNil(t, wt.ToStructs(&ws))
+
+	// bad shape
+	tbl.Data[0] = []any{"7"}
+	assert.NotNil(t, tbl.ToStructs(&conv))
+
+	_, e = TableFromStructs(recs, "missing")
+	assert.NotNil(t, e)
+}
+
+func TestTableSortFilter(t *testing.T) {
+	tbl := &Table{
+		RowNames: []string{"a", "b", "c", "d"},
+		ColNames: []string{"name", "grp", "x"},
+		Data: [][]any{
+			{"g2", "g1", "g2", "g1"},
+			{int64(3), int64(1), nil, 2.5},
+		},
+	}
+
+	srt, e := tbl.SortBy("x", true)
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"a", "d", "b", "c"}, srt.RowNames)
+	assert.Equal(t, []any{int64(3), 2.5, int64(1), nil}, srt.Data[1])
+
+	srt, e = tbl.SortByKeys([]string{"grp", "name"}, []bool{false, true})
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"d", "b", "c", "a"}, srt.RowNames)
+
+	flt, e := tbl.Filter("x", ">=", 2.5)
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"a", "d"}, flt.RowNames)
+
+	sel, e := tbl.Select("x")
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"name", "x"}, sel.ColNames)
+	assert.Equal(t, tbl.Data[1], sel.Data[0])
+
+	drp, e := tbl.Drop("x")
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"name", "grp"}, drp.ColNames)
+
+	_, e = tbl.Select("nope")
+	assert.NotNil(t, e)
+	_, e = tbl.Drop("name")
+	assert.NotNil(t, e)
+
+	tbl.Data[1] = []any{uint8(3), int64(1), int16(-2), 2.5}
+	srt, e = tbl.SortBy("x", false)
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"c", "b", "d", "a"}, srt.RowNames)
+
+	// int columns from TableFromStructs and UInt64 columns from ClickHouse
+	ints, e := TableFromStructs([]struct{ A int }{{1}, {4}}, "")
+	assert.Nil(t, e)
+	flt, e = ints.Filter("A", ">", 1)
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"2"}, flt.RowNames)
+
+	tbl.Data[1] = []any{uint64(3), uint64(1), uint64(7), uint64(2)}
+	flt, e = tbl.Filter("x", "<=", 2.5)
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"b", "d"}, flt.RowNames)
+	_, e = tbl.Filter("x", "~", 1)
+	assert.NotNil(t, e)
+}
+
+func TestTableGroupBy(t *testing.T) {
+	tbl := &Table{
+		RowNames: []string{"1", "2", "3", "4", "5"},
+		ColNames: []string{"id", "state", "year", "bal"},
+		Data: [][]any{
+			{"TX", "CA", "TX", "CA", "TX"},
+			{int64(2020), int64(2020), int64(2021), int64(2021), int64(2020)},
+			{int64(10), int64(20), int64(30), nil, int64(50)},
+		},
+	}
+
+	grp, e := tbl.GroupBy([]string{"state"}, map[string]string{"bal": "sum", "year": "max"})
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"CA", "TX"}, grp.RowNames)
+	assert.Equal(t, []string{"state", "bal_sum", "year_max"}, grp.ColNames)
+	assert.Equal(t, []any{int64(20), int64(90)}, grp.Data[0])
+	assert.Equal(t, []any{int64(2021), int64(2021)}, grp.Data[1])
+
+	grp, e = tbl.GroupBy([]string{"state", "year"}, map[string]string{"bal": "mean"})
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"CA, 2020", "CA, 2021", "TX, 2020", "TX, 2021"}, grp.RowNames)
+	assert.Equal(t, []any{20.0, nil, 30.0, 30.0}, grp.Data[2])
+
+	pvt, e := tbl.Pivot("state", "year", "bal", "count")
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"state", "2020", "2021"}, pvt.ColNames)
+	assert.Equal(t, []any{int64(1), int64(2)}, pvt.Data[0])
+	assert.Equal(t, []any{int64(0), int64(1)}, pvt.Data[1])
+
+	tbl.Data[1][3] = int64(2022)
+	pvt, e = tbl.Pivot("state", "year", "bal", "sum")
+	assert.Nil(t, e)
+	assert.Equal(t, []any{nil, int64(30)}, pvt.Data[1])
+	assert.Equal(t, []any{nil, nil}, pvt.Data[2])
+
+	_, e = tbl.GroupBy([]string{"state"}, map[string]string{"bal": "mode"})
+	assert.NotNil(t, e)
+
+	// ClickHouse returns count() as UInt64; keys of different int types group by value
+	tbl = &Table{
+		RowNames: []string{"1", "2", "3"},
+		ColNames: []string{"id", "key", "n"},
+		Data: [][]any{
+			{1, int64(1), int64(2)},
+			{uint64(3), uint64(4), int8(5)},
+		},
+	}
+
+	grp, e = tbl.GroupBy([]string{"key"}, map[string]string{"n": "mean"})
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"1", "2"}, grp.RowNames)
+	assert.Equal(t, []any{3.5, 5.0}, grp.Data[0])
+
+	grp, e = tbl.GroupBy([]string{"key"}, map[string]string{"n": "median"})
+	assert.Nil(t, e)
+	assert.Equal(t, []any{3.5, 5.0}, grp.Data[0])
+
+	pvt, e = tbl.Pivot("key", "key", "n", "sum")
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"key", "1", "2"}, pvt.ColNames)
+	assert.Equal(t, []any{int64(7), nil}, pvt.Data[0])
+}
+
+func TestColFormat(t *testing.T) {
+	specs := []string{"$;comma;.2", "pct", "pct;.0", "si", ".1", "date=Jan 2006", "%05d", ""}
+	ins := []any{-1234.5, 0.1234, 0.5, 1234567.0, 12345.67, time.Date(2023, 4, 30, 0, 0, 0, 0, time.UTC), 42, uint8(200)}
+	exp := []string{"-$1,234.50", "12.3%", "50%", "1.2M", "12345.7", "Apr 2023", "00042", "200"}
+
+	for ind, spec := range specs {
+		cf, e := ParseColFormat(spec)
+		assert.Nil(t, e)
+		assert.Equal(t, exp[ind], cf.Format(ins[ind]))
+	}
+
+	_, e := ParseColFormat("bogus")
+	assert.NotNil(t, e)
+
+	// the default matches PrettyString
+	for _, x := range []any{0.01234, 0.5, 3.14159, 1234.5, int64(1234567)} {
+		assert.Equal(t, PrettyString(x), (&ColFormat{}).Format(x))
+	}
+
+	// a zero Decimals is the default, not 0 places
+	assert.Equal(t, "1234.5", (&ColFormat{DateLayout: "Jan 2006"}).Format(1234.5))
+	assert.Equal(t, "1235", (&ColFormat{Decimals: Places(0)}).Format(1234.56))
+
+	tbl := testTable()
+	tbl.Formats = map[string]*ColFormat{"rate": {Percent: true, Decimals: Places(1)}}
+	act, e := tbl.Render(TableCSV, false)
+	assert.Nil(t, e)
+	assert.Equal(t, "name,count,rate,label\na,\"1,200\",25.0%,x|y\nb,3,1250.0%,z\n", act)
+}
+
+func TestPad(t *testing.T) {
+	ins := []string{"abc", "café", "café", "日本", "👍", "\x1b[1mbold\x1b[0m"}
+	exp := []int{3, 4, 4, 4, 2, 4}
+	for ind, in := range ins {
+		assert.Equal(t, exp[ind], DisplayWidth(in))
+	}
+
+	act := Pad([][]string{{"name", "n", "c"}, {"日本", "10", "x"}, {"é", "1", "abc"}}, 2, AlignLeft, AlignRight, AlignCenter)
+	assert.Equal(t, "name   n   c   \n日本  10   x   \né      1  abc  \n", act)
+
+	tbl := &Table{RowNames: []string{"日本", "b"}, ColNames: []string{"", "x"}, Data: [][]any{{int64(1200), int64(3)}}}
+	assert.Equal(t, "            x    \n日本    1,200    \nb           3    \n", tbl.String())
+}
+
+func TestTermString(t *testing.T) {
+	tbl := testTable()
+
+	act := tbl.TermString(&TermOptions{Width: 80, Box: true, MaxCell: 5})
+	exp := "┌──────┬───────┬───────┬───────┐\n" +
+		"│ name │ count │  rate │ label │\n" +
+		"├──────┼───────┼───────┼───────┤\n" +
+		"│ a    │ 1,200 │ 0.250 │ x|y   │\n" +
+		"│ b    │     3 │  12.5 │ z     │\n" +
+		"└──────┴───────┴───────┴───────┘\n"
+	assert.Equal(t, exp, act)
+
+	// narrow terminal: each page repeats the row name column
+	act = tbl.TermString(&TermOptions{Width: 20})
+	exp = "name    count    \na       1,200    \nb           3    \n\n" +
+		"name     rate    \na       0.250    \nb        12.5    \n\n" +
+		"name    label    \na       x|y      \nb       z        \n"
+	assert.Equal(t, exp, act)
+
+	assert.Equal(t, "日本…", Truncate("日本語です", 5, "…"))
+
+	// not a terminal
+	var sb strings.Builder
+	assert.Nil(t, tbl.Terminal(&sb, nil))
+	assert.Equal(t, tbl.String(), sb.String())
+
+	// bad shape
+	tbl.RowNames = tbl.RowNames[:1]
+	assert.Equal(t, "", tbl.TermString(nil))
+	assert.NotNil(t, tbl.Terminal(&sb, nil))
+}
+
+func TestChField(t *testing.T) {
+	cols := [][]any{
+		{int64(1), int32(2)},
+		{int64(1), 2.5, nil},
+		{time.Now(), nil},
+		{"a", int64(1)},
+		{nil, nil},
+	}
+	exp := []string{"Int64", "Nullable(Float64)", "Nullable(Date)", "String", "Nullable(String)"}
+
+	for ind, col := range cols {
+		assert.Equal(t, exp[ind], chField(col).String())
+	}
+
+	assert.Equal(t, `'O\'Brien'`, chLiteral("O'Brien"))
+	assert.Equal(t, "NULL", chLiteral(nil))
+	assert.Equal(t, "0.25", chLiteral(float32(0.25)))
+
+	// missing values are NULL, pointers are dereferenced
+	NAValues = []any{-999}
+	defer func() { NAValues = nil }()
+
+	n := int64(5)
+	assert.Equal(t, "Nullable(Float64)", chField([]any{1.5, math.NaN()}).String())
+	assert.Equal(t, "Nullable(Int64)", chField([]any{int64(1), int32(-999), &n}).String())
+	assert.Equal(t, "NULL", chLiteral(math.NaN()))
+	assert.Equal(t, "NULL", chLiteral(-999.0))
+	assert.Equal(t, "5", chLiteral(&n))
+	assert.Equal(t, "''", chString(""))
+}
+
+func TestTableTotals(t *testing.T) {
+	tbl := &Table{
+		RowNames: []string{"1", "2", "3"},
+		ColNames: []string{"id", "state", "n", "bal"},
+		Data: [][]any{
+			{"TX", "CA", "TX"},
+			{int64(1), int64(2), int64(3)},
+			{10.0, 20.0, nil},
+		},
+	}
+
+	assert.Nil(t, tbl.AddSubtotals("state", nil))
+	assert.Equal(t, []string{"2", "CA subtotal", "1", "3", "TX subtotal"}, tbl.RowNames)
+	assert.Equal(t, []any{"CA", "CA", "TX", "TX", "TX"}, tbl.Data[0])
+	assert.Equal(t, []any{int64(2), int64(2), int64(1), int64(3), int64(4)}, tbl.Data[1])
+
+	tbl = &Table{
+		RowNames: []string{"1", "2", "3"},
+		ColNames: []string{"id", "state", "n", "bal"},
+		Data: [][]any{
+			{"TX", "CA", "TX"},
+			{int64(1), int64(2), int64(3)},
+			{10.0, 20.0, nil},
+		},
+	}
+
+	assert.Nil(t, tbl.AddTotalRow("Total", nil))
+	assert.Equal(t, "Total", tbl.RowNames[3])
+	assert.Equal(t, []any{nil, int64(6), 30.0}, []any{tbl.Data[0][3], tbl.Data[1][3], tbl.Data[2][3]})
+
+	total := 30.0
+	e := tbl.AddColumn("share", func(row map[string]any) any {
+		if row["bal"] == nil {
+			return nil
+		}
+
+		return row["bal"].(float64) / total
+	})
+	assert.Nil(t, e)
+	assert.Equal(t, []any{1.0 / 3.0, 2.0 / 3.0, nil, 1.0}, tbl.Data[3])
+
+	assert.NotNil(t, tbl.AddColumn("n", func(map[string]any) any { return nil }))
+	assert.NotNil(t, tbl.AddTotalRow("Total", map[string]string{"nope": "sum"}))
+
+	// sums of any int kinds stay integers
+	tbl = &Table{
+		RowNames: []string{"1", "2"},
+		ColNames: []string{"id", "count", "small", "mixed"},
+		Data: [][]any{
+			{uint64(3), uint64(4)},
+			{int8(100), int8(100)},
+			{uint32(1), 0.5},
+		},
+	}
+
+	assert.Nil(t, tbl.AddTotalRow("Total", nil))
+	assert.Equal(t, []any{int64(7), int64(200), 1.5}, []any{tbl.Data[0][2], tbl.Data[1][2], tbl.Data[2][2]})
+}
+
+func TestTableStructure(t *testing.T) {
+	tbl := testTable()
+
+	tr, e := tbl.Transpose()
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"count", "rate", "label"}, tr.RowNames)
+	assert.Equal(t, []string{"name", "a", "b"}, tr.ColNames)
+	assert.Equal(t, []any{0.25, 12.5}, []any{tr.Data[0][1], tr.Data[1][1]})
+
+	other := &Table{RowNames: []string{"c"}, ColNames: []string{"name", "label", "rate", "count"},
+		Data: [][]any{{"w"}, {1.0}, {int64(9)}}}
+	app, e := tbl.AppendRows(other)
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"a", "b", "c"}, app.RowNames)
+	assert.Equal(t, []any{int64(1200), int64(3), int64(9)}, app.Data[0])
+
+	other = &Table{RowNames: []string{"a", "b"}, ColNames: []string{"name", "extra"}, Data: [][]any{{1, 2}}}
+	app, e = tbl.AppendCols(other)
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"name", "count", "rate", "label", "extra"}, app.ColNames)
+
+	other.RowNames = []string{"b", "a"}
+	_, e = tbl.AppendCols(other)
+	assert.NotNil(t, e)
+
+	other = &Table{RowNames: []string{"b", "c", "b"}, ColNames: []string{"name", "extra"}, Data: [][]any{{1, 2, 3}}}
+	kinds := []string{"inner", "left", "outer"}
+	expRows := [][]string{{"b", "b"}, {"a", "b", "b"}, {"a", "b", "b", "c"}}
+	expExtra := [][]any{{1, 3}, {nil, 1, 3}, {nil, 1, 3, 2}}
+	for ind, kind := range kinds {
+		jn, e := tbl.Join(other, "", kind)
+		assert.Nil(t, e)
+		assert.Equal(t, expRows[ind], jn.RowNames)
+		assert.Equal(t, expExtra[ind], jn.Data[3])
+	}
+
+	other = &Table{RowNames: []string{"1", "2"}, ColNames: []string{"id", "label", "code"}, Data: [][]any{{"z", "q"}, {7, 8}}}
+	jn, e := tbl.Join(other, "label", "outer")
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"a", "b", "2"}, jn.RowNames)
+	assert.Equal(t, []any{"x|y", "z", "q"}, jn.Data[2])
+	assert.Equal(t, []any{nil, 7, 8}, jn.Data[3])
+
+	other = &Table{RowNames: []string{"x"}, ColNames: []string{"id", "count", "code"}, Data: [][]any{{3}, {"c"}}}
+	jn, e = tbl.Join(other, "count", "inner")
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"b"}, jn.RowNames)
+	assert.Equal(t, []any{"c"}, jn.Data[3])
+
+	other.Data[0][0] = 3.0
+	jn, e = tbl.Join(other, "count", "inner")
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"b"}, jn.RowNames)
+
+	_, e = tbl.Join(other, "label", "cross")
+	assert.NotNil(t, e)
+	_, e = tbl.Join(tbl, "", "inner")
+	assert.NotNil(t, e)
+}
+
+func TestDiffTables(t *testing.T) {
+	a := testTable()
+	b := testTable()
+
+	diff, e := DiffTables(a, b, 0)
+	assert.Nil(t, e)
+	assert.Nil(t, diff.Err())
+
+	b.Data[1][0] = 0.2501
+	b.Data[2][1] = "zz"
+	b.RowNames = append(b.RowNames, "c")
+	for col := range b.Data {
+		b.Data[col] = append(b.Data[col], nil)
+	}
+
+	diff, e = DiffTables(a, b, 0.001)
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"c"}, diff.AddedRows)
+	assert.Equal(t, 1, len(diff.Cells))
+	assert.Equal(t, "zz", diff.Cells[0].B)
+	assert.NotNil(t, diff.Err())
+
+	tbl := diff.Table()
+	assert.Equal(t, []string{"a", "b", "+c"}, tbl.RowNames)
+	assert.Equal(t, "z → zz", tbl.Data[2][1])
+
+	diff, e = DiffTables(a, b, 0)
+	assert.Nil(t, e)
+	assert.Equal(t, 2, len(diff.Cells))
+
+	// the absolute difference alone is enough
+	a, b = testTable(), testTable()
+	a.Data[0][0], b.Data[0][0] = 1000.0, 1005.0
+	diff, e = DiffTables(a, b, 0.01)
+	assert.Nil(t, e)
+	assert.Equal(t, 1, len(diff.Cells))
+	assert.Equal(t, 5.0, diff.Cells[0].AbsDiff)
+
+	// as is the relative difference
+	a.Data[0][0], b.Data[0][0] = 0.001, 0.002
+	diff, e = DiffTables(a, b, 0.01)
+	assert.Nil(t, e)
+	assert.Equal(t, 1, len(diff.Cells))
+}
+
+func TestDescribe(t *testing.T) {
+	dt1, dt2 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	data := map[string][]any{
+		"x":    {1.0, 2.0, 3.0, 4.0, math.NaN()},
+		"st":   {"TX", "CA", "TX", "", nil},
+		"date": {dt2, dt1, dt2, nil, nil},
+	}
+
+	tbl := Describe(data)
+	assert.Equal(t, []string{"statistic", "date", "st", "x"}, tbl.ColNames)
+	assert.Equal(t, describeStats, tbl.RowNames)
+
+	stats := make(map[string][]any)
+	for row, name := range tbl.RowNames {
+		stats[name] = []any{tbl.Data[0][row], tbl.Data[1][row], tbl.Data[2][row]}
+	}
+
+	assert.Equal(t, []any{int64(3), int64(3), int64(4)}, stats["count"])
+	assert.Equal(t, []any{int64(2), int64(2), int64(1)}, stats["missing"])
+	assert.Equal(t, []any{nil, nil, 2.5}, stats["mean"])
+	assert.Equal(t, []any{dt1, nil, 1.0}, stats["min"])
+	assert.Equal(t, []any{dt2, nil, 4.0}, stats["max"])
+	assert.Equal(t, []any{int64(2), int64(2), int64(4)}, stats["distinct"])
+	assert.Equal(t, []any{dt2, "TX", nil}, stats["top"])
+	assert.Equal(t, []any{int64(2), int64(2), nil}, stats["freq"])
+	assert.Equal(t, 2.0, stats["median"][2])
+
+	// RowNames is a copy
+	tbl.RowNames[0] = "n"
+	assert.Equal(t, "count", describeStats[0])
+}
+
+func TestWriteXLSX(t *testing.T) {
+	tbl := testTable()
+	dt := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	tbl.ColNames = append(tbl.ColNames, "date")
+	tbl.Data = append(tbl.Data, []any{dt, nil})
+
+	outFile := TempFile("xlsx", 8)
+	defer func() { _ = os.Remove(outFile) }()
+
+	e := WriteWorkbook(outFile, Sheet{Name: "first", Table: tbl}, Sheet{Name: "second", Table: tbl})
+	assert.Nil(t, e)
+
+	rdr, e := zip.OpenReader(outFile)
+	assert.Nil(t, e)
+	defer func() { _ = rdr.Close() }()
+
+	parts := make(map[string]string)
+	for _, f := range rdr.File {
+		fh, e := f.Open()
+		assert.Nil(t, e)
+		body, e := io.ReadAll(fh)
+		assert.Nil(t, e)
+		_ = fh.Close()
+
+		// every part must be well-formed XML
+		dec := xml.NewDecoder(bytes.NewReader(body))
+		for {
+			if _, e = dec.Token(); e != nil {
+				break
+			}
+		}
+		assert.Equal(t, io.EOF, e, f.Name)
+
+		parts[f.Name] = string(body)
+	}
+
+	assert.Contains(t, parts, "xl/worksheets/sheet2.xml")
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, `<c r="B2" s="2"><v>1200</v></c>`)
+	assert.Contains(t, sheet, `<c r="C3"><v>12.5</v></c>`)
+	assert.Contains(t, sheet, `<c r="E2" s="3"><v>45352</v></c>`)
+	assert.Contains(t, sheet, `x|y`)
+	assert.Contains(t, sheet, `state="frozen"`)
+	assert.NotContains(t, sheet, `r="E3"`)
+
+	NAValues = []any{-999}
+	defer func() { NAValues = nil }()
+
+	n := 42
+	assert.Equal(t, "", xlsxCell("A1", -999.0))
+	assert.Equal(t, "", xlsxCell("A1", math.NaN()))
+	assert.Equal(t, `<c r="A1" s="2"><v>42</v></c>`, xlsxCell("A1", &n))
+
+	assert.NotNil(t, tbl.WriteXLSX(outFile, "bad/name"))
+	assert.NotNil(t, WriteWorkbook(outFile, Sheet{Name: "a", Table: tbl}, Sheet{Name: "A", Table: tbl}))
+	assert.NotNil(t, WriteWorkbook(outFile, Sheet{Name: "a"}))
+}
+
+func TestNA(t *testing.T) {
+	var nilPtr *int
+	seven := int8(7)
+	assert.True(t, IsNA(nil))
+	assert.True(t, IsNA(nilPtr))
+	assert.True(t, IsNA(math.NaN()))
+	assert.True(t, IsNA(""))
+	assert.True(t, IsNA(time.Time{}))
+	assert.False(t, IsNA(&seven))
+	assert.False(t, IsNA(false))
+	assert.False(t, IsNA(uint16(0)))
+
+	NAValues = []any{-999, "NA"}
+	NAToken = "NA"
+	defer func() { NAValues, NAToken = nil, "" }()
+
+	assert.True(t, IsNA(int64(-999)))
+	assert.True(t, IsNA(-999.0))
+	assert.True(t, IsNA("NA"))
+	assert.False(t, IsNA("na"))
+	assert.Equal(t, "NA", PrettyString(math.NaN()))
+	assert.Equal(t, "NA", (&ColFormat{}).Format(int32(-999)))
+	assert.Equal(t, "7", PrettyString(&seven))
+	assert.Equal(t, "1,000", PrettyString(uint32(1000)))
+	assert.Equal(t, "true", PrettyString(true))
+
+	tbl := &Table{
+		RowNames: []string{"a", "b", "c", "d"},
+		ColNames: []string{"", "x", "y", "z"},
+		Data: [][]any{
+			{int8(1), nil, "NA", uint(3)},
+			{nil, nil, math.NaN(), true},
+			{"", nil, -999, nil},
+		},
+	}
+
+	rows := *tbl
+	rows.CleanUp()
+	assert.Equal(t, []string{"a", "d"}, rows.RowNames)
+
+	rows = *tbl
+	rows.DropNARows()
+	assert.Nil(t, rows.RowNames)
+	assert.Equal(t, 3, len(rows.Data))
+
+	cols := *tbl
+	cols.DropEmptyCols()
+	assert.Equal(t, []string{"", "x", "y"}, cols.ColNames)
+	assert.Equal(t, 2, len(cols.Data))
+
+	empty := &Table{}
+	empty.CleanUp()
+	empty.DropEmptyCols()
+	assert.Equal(t, 0, len(empty.Data))
+}
+
+func TestTableWriter(t *testing.T) {
+	tbl := testTable()
+
+	// streaming the whole table matches Render
+	for format := TablePlain; format <= TableLaTeX; format++ {
+		var sb strings.Builder
+		assert.Nil(t, tbl.Stream(&sb, format, false))
+		exp, e := tbl.Render(format, false)
+		assert.Nil(t, e)
+		assert.Equal(t, exp, sb.String(), format.String())
+	}
+
+	var sb strings.Builder
+	tw, e := NewTableWriter(&sb, TablePlain, []string{"id", "x"}, &StreamOptions{Widths: []int{2, 5}, Align: []Align{AlignLeft, AlignRight}})
+	assert.Nil(t, e)
+	for row := 0; row < 3; row++ {
+		assert.Nil(t, tw.WriteRow(fmt.Sprintf("%d", row), row*1000))
+	}
+	assert.NotNil(t, tw.WriteRow("bad", 1, 2))
+	assert.Nil(t, tw.Close())
+	assert.Equal(t, "id        x    \n0         0    \n1     1,000    \n2     2,000    \n", sb.String())
+
+	// widths from a sample
+	sb.Reset()
+	tw, e = NewTableWriter(&sb, TableMarkdown, tbl.ColNames, SampleOptions(tbl, TableMarkdown, false))
+	assert.Nil(t, e)
+	assert.Nil(t, tw.WriteRow("c", 5, 1.5, "w"))
+	assert.Nil(t, tw.Close())
+	assert.Equal(t, "| name | count |  rate | label |\n| :--- | ----: | ----: | :---- |\n| c    |     5 |  1.50 | w     |\n", sb.String())
+
+	_, e = NewTableWriter(&sb, TablePlain, []string{"a", "b"}, &StreamOptions{Widths: []int{1}})
+	assert.NotNil(t, e)
+}
+
+func TestTablePlotly(t *testing.T) {
+	tbl := testTable()
+
+	fig, e := tbl.ToPlotlyTable()
+	assert.Nil(t, e)
+	tr := fig.Data[0].(*grob.Table)
+	assert.Equal(t, tbl.ColNames, tr.Header.Values)
+	assert.Equal(t, [][]string{{"a", "b"}, {"1,200", "3"}, {"0.250", "12.5"}, {"x|y", "z"}}, tr.Cells.Values)
+
+	_, e = tbl.ToHeatmap()
+	assert.NotNil(t, e)
+
+	tbl, e = tbl.Select("count", "rate")
+	assert.Nil(t, e)
+	tbl.Data[1][1] = math.NaN()
+	fig, e = tbl.ToHeatmap()
+	assert.Nil(t, e)
+	hm := fig.Data[0].(*grob.Heatmap)
+	assert.Equal(t, []string{"b", "a"}, hm.Y)
+	assert.Equal(t, [][]any{{3.0, nil}, {1200.0, 0.25}}, hm.Z)
+	assert.Equal(t, "0.250", hm.Text.([][]string)[1][1])
+}
+
+func TestSets(t *testing.T) {
+	assert.Equal(t, 2, Index(3, 1, 2, 3, 3))
+	assert.Equal(t, -1, Index("x", "a,x"))
+	assert.True(t, Contains(2.5, 1.0, 2.5))
+	assert.False(t, Contains(4, 1, 2))
+
+	assert.Equal(t, []int{3, 1, 2}, Unique([]int{3, 1, 3, 2, 1}))
+	assert.Equal(t, []int{1, 2, 3}, UniqueSorted([]int{3, 1, 3, 2, 1}))
+	assert.Nil(t, Unique([]string{}))
+	assert.Equal(t, []string{"a,b", "a"}, DeDupeSlice([]string{"a,b", "a", "a,b"}, false))
+	assert.Equal(t, []string{"a", "b", "c"}, DeDupeSlice([]string{"c", "a", "b", "a"}, true))
+
+	dt1, dt2, dt3 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	a, b := []time.Time{dt1, dt2, dt1}, []time.Time{dt3, dt2}
+	assert.Equal(t, []time.Time{dt1, dt2, dt3}, Union(a, b))
+	assert.Equal(t, []time.Time{dt2}, Intersection(a, b))
+	assert.Equal(t, []time.Time{dt1}, Difference(a, b))
+	assert.Nil(t, Difference(b, append(a, dt3)))
+}
+
+func TestMatchedAll(t *testing.T) {
+	act, e := MatchedAll(`{{a {{b}} }} x {{c}}`, "{{", "}}")
+	assert.Nil(t, e)
+	assert.Equal(t, []Match{
+		{Text: "a {{b}} ", Start: 0, End: 12, Depth: 0},
+		{Text: "b", Start: 4, End: 9, Depth: 1},
+		{Text: "c", Start: 15, End: 20, Depth: 0},
+	}, act)
+
+	act, e = MatchedAll(`f(")", 'it''s', \(, g(x)) (y)`, "(", ")", `"`, "'")
+	assert.Nil(t, e)
+	assert.Equal(t, 3, len(act))
+	assert.Equal(t, `")", 'it''s', \(, g(x)`, act[0].Text)
+	assert.Equal(t, "x", act[1].Text)
+	assert.Equal(t, "y", act[2].Text)
+
+	act, e = MatchedAll("BEGIN x BEGIN y END END", "BEGIN", "END")
+	assert.Nil(t, e)
+	assert.Equal(t, " x BEGIN y END ", act[0].Text)
+
+	act, e = MatchedAll("a $x$ b $y$", "$", "$")
+	assert.Nil(t, e)
+	assert.Equal(t, []string{"x", "y"}, []string{act[0].Text, act[1].Text})
+
+	act, e = matchAll(`('a') 'unterminated`, "(", ")", []string{"'"}, true)
+	assert.Nil(t, e)
+	assert.Equal(t, []Match{{Text: "'a'", Start: 0, End: 5}}, act)
+
+	for _, bad := range []string{"a(b", "a)b", `a("b)`} {
+		_, e = MatchedAll(bad, "(", ")", `"`)
+		assert.NotNil(t, e, bad)
+	}
+}
+
+func TestReplaceQuoted(t *testing.T) {
+	act, e := ReplaceQuoted(`SELECT a AND b FROM t WHERE c = 'x AND y' AND d = "AND"`, " AND ", " && ", "'", `"`)
+	assert.Nil(t, e)
+	assert.Equal(t, `SELECT a && b FROM t WHERE c = 'x AND y' && d = "AND"`, act)
+
+	act, e = ReplaceQuoted(`'it''s, here', x, 'a\', b', y`, ",", ";", "'")
+	assert.Nil(t, e)
+	assert.Equal(t, `'it''s, here'; x; 'a\', b'; y`, act)
+
+	act, e = ReplaceQuoted(`a\,b,c`, ",", "")
+	assert.Nil(t, e)
+	assert.Equal(t, `a\,bc`, act)
+
+	act, e = ReplaceQuoted(`a\nb '\n'`, `\n`, "X", "'")
+	assert.Nil(t, e)
+	assert.Equal(t, `aXb '\n'`, act)
+
+	act, e = ReplaceQuoted("$$x, y$$, z", ",", "|", "$$")
+	assert.Nil(t, e)
+	assert.Equal(t, "$$x, y$$| z", act)
+
+	_, e = ReplaceQuoted("a 'b", " ", "", "'")
+	assert.NotNil(t, e)
+	_, e = ReplaceQuoted("a", "", "b")
+	assert.NotNil(t, e)
+}
+
+func TestParseBool(t *testing.T) {
+	for _, in := range []string{"TRUE", " y ", "1", "On", "yes"} {
+		act, e := ParseBool(in, nil)
+		assert.Nil(t, e)
+		assert.True(t, act, in)
+	}
+
+	for _, in := range []string{"False", "n", "0", "OFF"} {
+		act, e := ParseBool(in, nil)
+		assert.Nil(t, e)
+		assert.False(t, act, in)
+	}
+
+	_, e := ParseBool("maybe", nil)
+	assert.NotNil(t, e)
+	assert.Contains(t, e.Error(), "0, 1, f, false")
+
+	act, e := ParseBool("Ja", map[string]bool{"ja": true, "nein": false})
+	assert.Nil(t, e)
+	assert.True(t, act)
+}
+
+func TestParseEnum(t *testing.T) {
+	images := []PlotlyImage{PlotlyJPEG, PlotlyPNG, PlotlyHTML, PlotlyPDF, PlotlyWEBP, PlotlySVG, PlotlyEPS, PlotlyEMF}
+
+	act, e := ParseEnum("PNG", images...)
+	assert.Nil(t, e)
+	assert.Equal(t, PlotlyPNG, act)
+
+	act, e = ParseEnum("w", images...)
+	assert.Nil(t, e)
+	assert.Equal(t, PlotlyWEBP, act)
+
+	_, e = ParseEnum("e", images...)
+	assert.NotNil(t, e)
+	assert.Contains(t, e.Error(), "eps, emf")
+
+	_, e = ParseEnum("gif", images...)
+	assert.NotNil(t, e)
+	assert.Contains(t, e.Error(), "jpeg, png, html")
+}
+
+func TestFuzzy(t *testing.T) {
+	assert.Equal(t, 3, Levenshtein("kitten", "sitting"))
+	assert.Equal(t, 0, Levenshtein("", ""))
+	assert.Equal(t, 2, Levenshtein("ab", "ba"))
+	assert.Equal(t, 1, DamerauLevenshtein("ab", "ba"))
+	assert.Equal(t, 3, DamerauLevenshtein("ca", "abc"))
+	assert.Equal(t, 1, Levenshtein("日本", "日"))
+
+	assert.InDelta(t, 0.961, JaroWinkler("MARTHA", "MARHTA"), 0.001)
+	assert.InDelta(t, 0.813, JaroWinkler("DIXON", "DICKSONX"), 0.001)
+	assert.Equal(t, 1.0, JaroWinkler("same", "same"))
+	assert.Equal(t, 0.0, JaroWinkler("abc", "xyz"))
+
+	fields := []string{"loan_id", "balance", "rate", "balance_orig"}
+	assert.Equal(t, []string{"balance", "rate"}, ClosestMatch("balnce", fields, 2))
+	assert.Equal(t, []string{"balance_orig"}, ClosestMatch("balance_org", fields, 1))
+	assert.Equal(t, 4, len(ClosestMatch("x", fields, 0)))
+	assert.Equal(t, ", did you mean 'balance'?", DidYouMean("Balnce", fields))
+	assert.Equal(t, "", DidYouMean("zzz", fields))
+	assert.Equal(t, "unknown field 'rte', did you mean 'rate'?", unknownField("rte", fields).Error())
+}
+
+func TestRand(t *testing.T) {
+	r1, r2 := NewRand(42), NewRand(42)
+
+	x1, e := r1.UnifInt(100, 10)
+	assert.Nil(t, e)
+	x2, e := r2.UnifInt(100, 10)
+	assert.Nil(t, e)
+	assert.Equal(t, x1, x2)
+	for _, x := range x1 {
+		assert.True(t, x >= 0 && x < 10)
+	}
+
+	u1, _ := r1.UnifFlt(1000)
+	u2, _ := r2.UnifFlt(1000)
+	assert.Equal(t, u1, u2)
+	for _, u := range u1 {
+		assert.True(t, u > 0 && u < 1)
+	}
+
+	n1, _ := r1.Norm(10000)
+	n2, _ := r2.Norm(10000)
+	assert.Equal(t, n1, n2)
+	assert.InDelta(t, 0.0, stat.Mean(n1, nil), 0.05)
+	assert.InDelta(t, 1.0, stat.StdDev(n1, nil), 0.05)
+
+	assert.Equal(t, r1.Letters(40), r2.Letters(40))
+	assert.NotEqual(t, NewRand(1).Letters(20), NewRand(2).Letters(20))
+
+	_, e = r1.UnifInt(1, 0)
+	assert.NotNil(t, e)
+	_, e = r1.UnifInt(-1, 10)
+	assert.NotNil(t, e)
+	_, e = r1.UnifFlt(-1)
+	assert.NotNil(t, e)
+	_, e = r1.Norm(-1)
+	assert.NotNil(t, e)
+
+	cr := NewCryptoRand()
+	xs, e := cr.UnifInt(50, 3)
+	assert.Nil(t, e)
+	assert.Equal(t, 50, len(xs))
+	assert.Equal(t, 30, len(cr.Letters(30)))
+}